@@ -0,0 +1,149 @@
+// Package archive writes the whole blog to a zip file and restores it.
+//
+// The archive layout is
+//
+//	manifest.json
+//	authors.json
+//	comments.json
+//	votes.json
+//	about.json
+//	posts.json                      language, version and password of each post
+//	translations.json
+//	attachments.json
+//	collaborators.json
+//	share_links.json
+//	posts/<id>-<slug>/index.md      post with YAML front matter
+//	posts/<id>-<slug>/files/...     copy of uploads/<id>
+//
+// Post bodies are the editor HTML, which is valid Markdown, so the posts can
+// also be read by other static site generators. Trashed posts are not
+// exported, and neither is anything that belongs to them.
+//
+// posts.json and share_links.json hold password and token hashes, so the
+// archive must be kept as safe as the database.
+package archive
+
+import "time"
+
+// FormatVersion is increased when the archive layout changes
+const FormatVersion = 2
+
+// ManifestFile marks the root of an archive
+const ManifestFile = "manifest.json"
+
+// Manifest describes an archive
+type Manifest struct {
+	Version       int       `json:"version"`
+	ExportedAt    time.Time `json:"exported_at"`
+	Posts         int       `json:"posts"`
+	Comments      int       `json:"comments"`
+	Votes         int       `json:"votes"`
+	AboutInfos    int       `json:"about_infos"`
+	Translations  int       `json:"translations"`
+	Attachments   int       `json:"attachments"`
+	Collaborators int       `json:"collaborators"`
+	ShareLinks    int       `json:"share_links"`
+}
+
+// Author identifies a user so references can be matched by email on restore
+type Author struct {
+	ID        uint   `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+}
+
+// Comment is a comment without the commenter's IP address
+type Comment struct {
+	ID        uint       `json:"id"`
+	PostID    uint       `json:"post_id"`
+	UserID    *uint      `json:"user_id"`
+	ParentID  *uint      `json:"parent_id"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at"`
+}
+
+// Vote is a comment reaction without the voter's IP address
+type Vote struct {
+	ID        uint      `json:"id"`
+	CommentID uint      `json:"comment_id"`
+	UserID    *uint     `json:"user_id"`
+	Emoji     string    `json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostMeta holds the post fields that are not part of the front matter
+type PostMeta struct {
+	ID        uint   `json:"id"`
+	Lang      string `json:"lang"`
+	Version   uint   `json:"version"`
+	Protected bool   `json:"protected"`
+	Password  []byte `json:"password,omitempty"` // bcrypt hash
+}
+
+// Translation is a language variant of a post. Links to the post's uploads
+// point at files/ like the post body.
+type Translation struct {
+	PostID    uint      `json:"post_id"`
+	Lang      string    `json:"lang"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Attachment describes a file of the post's files/ directory
+type Attachment struct {
+	PostID        uint      `json:"post_id"`
+	Filename      string    `json:"filename"`
+	Name          string    `json:"name"`
+	Size          int64     `json:"size"`
+	MimeType      string    `json:"mime_type"`
+	Position      int       `json:"position"`
+	DownloadCount int       `json:"download_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Collaborator is a user invited to a post
+type Collaborator struct {
+	PostID     uint       `json:"post_id"`
+	UserID     uint       `json:"user_id"`
+	InvitedBy  uint       `json:"invited_by"`
+	CanEdit    bool       `json:"can_edit"`
+	CanPublish bool       `json:"can_publish"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ShareLink is a share link of a post. Only the token hash is exported, so
+// restored links keep working with the tokens already handed out.
+type ShareLink struct {
+	PostID        uint       `json:"post_id"`
+	TokenHash     string     `json:"token_hash"`
+	TokenHint     string     `json:"token_hint"`
+	Note          string     `json:"note"`
+	AllowComments bool       `json:"allow_comments"`
+	CreatedBy     uint       `json:"created_by"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// frontMatter is the YAML header of an exported post
+type frontMatter struct {
+	ID          uint       `yaml:"id"`
+	Title       string     `yaml:"title"`
+	Slug        string     `yaml:"slug,omitempty"`
+	Date        time.Time  `yaml:"date"`
+	Lastmod     *time.Time `yaml:"lastmod,omitempty"`
+	Tags        []string   `yaml:"tags,omitempty"`
+	Categories  []string   `yaml:"categories,omitempty"`
+	Author      string     `yaml:"author,omitempty"`
+	AuthorEmail string     `yaml:"author_email,omitempty"`
+	Hidden      bool       `yaml:"hidden"`
+	File        string     `yaml:"file,omitempty"`
+	Format      string     `yaml:"format"`
+}
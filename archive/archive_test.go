@@ -0,0 +1,137 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/importer"
+	"github.com/bloomingFlower/blog-backend/models"
+)
+
+func TestLocalLinks(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`<img src="https://blog.example.com/api/v1/uploads/7/a.png">`, `<img src="files/a.png">`},
+		{`<a href="/api/v1/download/7/b.pdf">b</a>`, `<a href="files/b.pdf">b</a>`},
+		{`<img src="//cdn.example.com/api/v1/uploads/7/c.png">`, `<img src="files/c.png">`},
+		// 다른 포스트의 파일은 그대로 둠
+		{`<img src="https://blog.example.com/api/v1/uploads/8/a.png">`, `<img src="https://blog.example.com/api/v1/uploads/8/a.png">`},
+		{`<img src="https://blog.example.com/api/v1/uploads/70/a.png">`, `<img src="https://blog.example.com/api/v1/uploads/70/a.png">`},
+		{`<img src="https://other.example.com/a.png">`, `<img src="https://other.example.com/a.png">`},
+	}
+	for _, tt := range tests {
+		if got := localLinks(tt.content, 7); got != tt.want {
+			t.Errorf("localLinks(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFileLink(t *testing.T) {
+	content := `<img src="files/a.png"> <a href='files/b.pdf'>b</a> files/c.txt <img data-src="files/d.png">`
+	// 지연 로딩 이미지의 data-src도 바꿈
+	want := `<img src="https://blog.example.com/api/v1/uploads/9/a.png"> <a href='https://blog.example.com/api/v1/uploads/9/b.pdf'>b</a> files/c.txt <img data-src="https://blog.example.com/api/v1/uploads/9/d.png">`
+	if got := fileLink.ReplaceAllString(content, "${1}https://blog.example.com/api/v1/uploads/9/"); got != want {
+		t.Errorf("fileLink rewrite = %q, want %q", got, want)
+	}
+}
+
+func TestPostDir(t *testing.T) {
+	if got := postDir(models.Post{ID: 3}); got != "posts/3" {
+		t.Errorf("postDir without slug = %q", got)
+	}
+	if got := postDir(models.Post{ID: 3, Slug: "hello"}); got != "posts/3-hello" {
+		t.Errorf("postDir = %q", got)
+	}
+}
+
+// TestWritePostRoundTrip writes a post into an archive and reads it back
+// with the importer like Restore does
+func TestWritePostRoundTrip(t *testing.T) {
+	uploads := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(uploads, "7"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(uploads, "7", "a.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	post := models.Post{
+		ID:        7,
+		Title:     "Hello: a \"quoted\" title",
+		Slug:      "hello",
+		Content:   `<p>Hi</p><img src="https://blog.example.com/api/v1/uploads/7/a.png">`,
+		Tags:      "go, web,",
+		Category:  "notes",
+		File:      "files/7/a.png",
+		Hidden:    true,
+		CreatedAt: time.Date(2021, 3, 4, 10, 20, 0, 0, time.UTC),
+		UpdatedAt: &updated,
+		User:      models.User{FirstName: "Minji", LastName: "Kim", Email: "kim@example.com"},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writePost(zw, post, uploads); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		target := filepath.Join(dir, filepath.FromSlash(f.Name))
+		os.MkdirAll(filepath.Dir(target), 0755)
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(names)
+	if want := []string{"posts/7-hello/files/a.png", "posts/7-hello/index.md"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("archive holds %q, want %q", names, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "posts", "7-hello", "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := importer.ParseMarkdown(dir, "posts/7-hello/index.md", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.SourceID != "7" || doc.Title != post.Title || doc.Slug != "hello" || !doc.Hidden ||
+		doc.AuthorEmail != "kim@example.com" || doc.Author != "Minji Kim" || doc.File != "a.png" {
+		t.Errorf("document = %+v", doc)
+	}
+	if !doc.Date.Equal(post.CreatedAt) || doc.Updated == nil || !doc.Updated.Equal(updated) {
+		t.Errorf("dates = %v, %v", doc.Date, doc.Updated)
+	}
+	if !reflect.DeepEqual(doc.Tags, []string{"go", "web"}) || !reflect.DeepEqual(doc.Categories, []string{"notes"}) {
+		t.Errorf("tags = %q, categories = %q", doc.Tags, doc.Categories)
+	}
+	if want := `<p>Hi</p><img src="files/a.png">`; doc.Content != want {
+		t.Errorf("content = %q, want %q", doc.Content, want)
+	}
+}
@@ -0,0 +1,357 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/models"
+	"gopkg.in/yaml.v3"
+)
+
+// uploadLink matches links to a post's uploaded files
+var uploadLink = regexp.MustCompile(`((?:https?:)?//[^"'\s()<>]*)?/api/v1/(?:uploads|download)/(\d+)/`)
+
+// Export writes the whole blog as a zip archive to w
+func Export(w io.Writer, uploadDir string) error {
+	var posts []models.Post
+	if err := database.DB.Preload("User").Order("id").Find(&posts).Error; err != nil {
+		return err
+	}
+
+	// Everything else is limited to the exported posts, so nothing of a
+	// trashed post ends up in the archive
+	postIDs := database.DB.Model(&models.Post{}).Select("id")
+
+	// Deleted comments are exported too, so their replies keep a parent
+	var comments []models.Comment
+	if err := database.DB.Unscoped().Where("post_id IN (?)", postIDs).Order("id").Find(&comments).Error; err != nil {
+		return err
+	}
+
+	var votes []models.Vote
+	commentIDs := database.DB.Unscoped().Model(&models.Comment{}).Select("id").Where("post_id IN (?)", postIDs)
+	if err := database.DB.Where("comment_id IN (?)", commentIDs).Order("id").Find(&votes).Error; err != nil {
+		return err
+	}
+
+	var translations []models.PostTranslation
+	if err := database.DB.Where("post_id IN (?)", postIDs).Order("id").Find(&translations).Error; err != nil {
+		return err
+	}
+
+	var attachments []models.PostAttachment
+	if err := database.DB.Where("post_id IN (?)", postIDs).Order("id").Find(&attachments).Error; err != nil {
+		return err
+	}
+
+	var collaborators []models.PostCollaborator
+	if err := database.DB.Where("post_id IN (?)", postIDs).Order("id").Find(&collaborators).Error; err != nil {
+		return err
+	}
+
+	var shareLinks []models.PostShareLink
+	if err := database.DB.Where("post_id IN (?)", postIDs).Order("id").Find(&shareLinks).Error; err != nil {
+		return err
+	}
+
+	var about []models.AboutInfo
+	if err := database.DB.Preload("Contacts").Preload("Sections.Items").Order("id").Find(&about).Error; err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	manifest := Manifest{
+		Version:       FormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Posts:         len(posts),
+		Comments:      len(comments),
+		Votes:         len(votes),
+		AboutInfos:    len(about),
+		Translations:  len(translations),
+		Attachments:   len(attachments),
+		Collaborators: len(collaborators),
+		ShareLinks:    len(shareLinks),
+	}
+	if err := writeJSON(zw, ManifestFile, manifest); err != nil {
+		return err
+	}
+
+	userIDs := make(map[uint]bool)
+	metas := make([]PostMeta, 0, len(posts))
+	for _, post := range posts {
+		userIDs[post.UserID] = true
+		if err := writePost(zw, post, uploadDir); err != nil {
+			return fmt.Errorf("post %d: %w", post.ID, err)
+		}
+		metas = append(metas, PostMeta{
+			ID:        post.ID,
+			Lang:      post.Lang,
+			Version:   post.Version,
+			Protected: post.Protected,
+			Password:  post.Password,
+		})
+	}
+	if err := writeJSON(zw, "posts.json", metas); err != nil {
+		return err
+	}
+
+	exportedComments := make([]Comment, 0, len(comments))
+	for _, comment := range comments {
+		if comment.UserID != nil {
+			userIDs[*comment.UserID] = true
+		}
+		c := Comment{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			UserID:    comment.UserID,
+			ParentID:  comment.ParentID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		}
+		if comment.DeletedAt.Valid {
+			c.DeletedAt = &comment.DeletedAt.Time
+		}
+		exportedComments = append(exportedComments, c)
+	}
+	if err := writeJSON(zw, "comments.json", exportedComments); err != nil {
+		return err
+	}
+
+	exportedVotes := make([]Vote, 0, len(votes))
+	for _, vote := range votes {
+		if vote.UserID != nil {
+			userIDs[*vote.UserID] = true
+		}
+		exportedVotes = append(exportedVotes, Vote{
+			ID:        vote.ID,
+			CommentID: vote.CommentID,
+			UserID:    vote.UserID,
+			Emoji:     vote.Emoji,
+			CreatedAt: vote.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, "votes.json", exportedVotes); err != nil {
+		return err
+	}
+
+	exportedTranslations := make([]Translation, 0, len(translations))
+	for _, t := range translations {
+		exportedTranslations = append(exportedTranslations, Translation{
+			PostID:    t.PostID,
+			Lang:      t.Lang,
+			Title:     t.Title,
+			Slug:      t.Slug,
+			Content:   localLinks(t.Content, t.PostID),
+			CreatedAt: t.CreatedAt,
+			UpdatedAt: t.UpdatedAt,
+		})
+	}
+	if err := writeJSON(zw, "translations.json", exportedTranslations); err != nil {
+		return err
+	}
+
+	exportedAttachments := make([]Attachment, 0, len(attachments))
+	for _, a := range attachments {
+		exportedAttachments = append(exportedAttachments, Attachment{
+			PostID:        a.PostID,
+			Filename:      a.Filename,
+			Name:          a.Name,
+			Size:          a.Size,
+			MimeType:      a.MimeType,
+			Position:      a.Position,
+			DownloadCount: a.DownloadCount,
+			CreatedAt:     a.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, "attachments.json", exportedAttachments); err != nil {
+		return err
+	}
+
+	exportedCollaborators := make([]Collaborator, 0, len(collaborators))
+	for _, pc := range collaborators {
+		userIDs[pc.UserID] = true
+		userIDs[pc.InvitedBy] = true
+		exportedCollaborators = append(exportedCollaborators, Collaborator{
+			PostID:     pc.PostID,
+			UserID:     pc.UserID,
+			InvitedBy:  pc.InvitedBy,
+			CanEdit:    pc.CanEdit,
+			CanPublish: pc.CanPublish,
+			AcceptedAt: pc.AcceptedAt,
+			CreatedAt:  pc.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, "collaborators.json", exportedCollaborators); err != nil {
+		return err
+	}
+
+	exportedShareLinks := make([]ShareLink, 0, len(shareLinks))
+	for _, link := range shareLinks {
+		userIDs[link.CreatedBy] = true
+		exportedShareLinks = append(exportedShareLinks, ShareLink{
+			PostID:        link.PostID,
+			TokenHash:     link.TokenHash,
+			TokenHint:     link.TokenHint,
+			Note:          link.Note,
+			AllowComments: link.AllowComments,
+			CreatedBy:     link.CreatedBy,
+			ExpiresAt:     link.ExpiresAt,
+			RevokedAt:     link.RevokedAt,
+			CreatedAt:     link.CreatedAt,
+		})
+	}
+	if err := writeJSON(zw, "share_links.json", exportedShareLinks); err != nil {
+		return err
+	}
+
+	if err := writeJSON(zw, "about.json", about); err != nil {
+		return err
+	}
+
+	authors := []Author{}
+	if len(userIDs) > 0 {
+		ids := make([]uint, 0, len(userIDs))
+		for id := range userIDs {
+			ids = append(ids, id)
+		}
+		var users []models.User
+		if err := database.DB.Where("id IN ?", ids).Order("id").Find(&users).Error; err != nil {
+			return err
+		}
+		for _, user := range users {
+			authors = append(authors, Author{
+				ID:        user.ID,
+				FirstName: user.FirstName,
+				LastName:  user.LastName,
+				Email:     user.Email,
+			})
+		}
+	}
+	if err := writeJSON(zw, "authors.json", authors); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// localLinks points the links to the post's uploads at the copies in files/
+func localLinks(content string, postID uint) string {
+	return uploadLink.ReplaceAllStringFunc(content, func(link string) string {
+		m := uploadLink.FindStringSubmatch(link)
+		if m[2] == strconv.Itoa(int(postID)) {
+			return "files/"
+		}
+		return link
+	})
+}
+
+// postDir returns the directory of a post inside the archive
+func postDir(post models.Post) string {
+	if post.Slug == "" {
+		return fmt.Sprintf("posts/%d", post.ID)
+	}
+	return fmt.Sprintf("posts/%d-%s", post.ID, post.Slug)
+}
+
+func writePost(zw *zip.Writer, post models.Post, uploadDir string) error {
+	dir := postDir(post)
+
+	fm := frontMatter{
+		ID:          post.ID,
+		Title:       post.Title,
+		Slug:        post.Slug,
+		Date:        post.CreatedAt,
+		Lastmod:     post.UpdatedAt,
+		Author:      strings.TrimSpace(post.User.FirstName + " " + post.User.LastName),
+		AuthorEmail: post.User.Email,
+		Hidden:      post.Hidden,
+		Format:      "html",
+	}
+	for _, tag := range strings.Split(post.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			fm.Tags = append(fm.Tags, tag)
+		}
+	}
+	if post.Category != "" {
+		fm.Categories = []string{post.Category}
+	}
+	if post.File != "" {
+		fm.File = filepath.Base(post.File)
+	}
+
+	header, err := yaml.Marshal(fm)
+	if err != nil {
+		return err
+	}
+
+	// Links to the post's uploads point at the copies next to index.md
+	content := localLinks(post.Content, post.ID)
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(header)
+	buf.WriteString("---\n")
+	buf.WriteString(content)
+
+	f, err := zw.Create(dir + "/index.md")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(uploadDir, strconv.Itoa(int(post.ID))))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := writeFile(zw, dir+"/files/"+entry.Name(), filepath.Join(uploadDir, strconv.Itoa(int(post.ID)), entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
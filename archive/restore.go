@@ -0,0 +1,362 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/importer"
+	"github.com/bloomingFlower/blog-backend/models"
+	"gorm.io/gorm"
+)
+
+// RestoreReport summarizes a restore
+type RestoreReport struct {
+	Posts         *importer.Report `json:"posts"`
+	Comments      int              `json:"comments"`
+	Votes         int              `json:"votes"`
+	AboutInfos    int              `json:"about_infos"`
+	Translations  int              `json:"translations"`
+	Attachments   int              `json:"attachments"`
+	Collaborators int              `json:"collaborators"`
+	ShareLinks    int              `json:"share_links"`
+	Warnings      []string         `json:"warnings,omitempty"`
+}
+
+// fileLink matches the links to files/ written by the export
+var fileLink = regexp.MustCompile(`(\b(?:src|href)=["'])files/`)
+
+// IsArchive reports whether dir is the root of an extracted archive
+func IsArchive(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ManifestFile))
+	return err == nil
+}
+
+// Restore imports an extracted archive. Posts are matched by their original
+// ID, so restoring the same archive twice updates them in place. Comments,
+// votes, translations, attachments, collaborators and share links are only
+// restored for posts created by this run, and an AboutInfo is skipped when
+// one with the same name and title already exists.
+func Restore(dir string, opts importer.Options) (*RestoreReport, error) {
+	var manifest Manifest
+	if err := readJSON(dir, ManifestFile, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", manifest.Version)
+	}
+
+	docs := []importer.Document{}
+	postsDir := filepath.Join(dir, "posts")
+	if _, err := os.Stat(postsDir); err == nil {
+		loaded, err := importer.LoadPath(postsDir)
+		if err != nil {
+			return nil, err
+		}
+		docs = loaded
+	}
+	for i := range docs {
+		docs[i].Source = importer.SourceExport
+		entries, err := os.ReadDir(filepath.Join(docs[i].Dir, "files"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				docs[i].Attachments = append(docs[i].Attachments, "files/"+entry.Name())
+			}
+		}
+	}
+
+	var authors []Author
+	var comments []Comment
+	var votes []Vote
+	var about []models.AboutInfo
+	var metas []PostMeta
+	var translations []Translation
+	var attachments []Attachment
+	var collaborators []Collaborator
+	var shareLinks []ShareLink
+	for name, v := range map[string]interface{}{
+		"authors.json":       &authors,
+		"comments.json":      &comments,
+		"votes.json":         &votes,
+		"about.json":         &about,
+		"posts.json":         &metas,
+		"translations.json":  &translations,
+		"attachments.json":   &attachments,
+		"collaborators.json": &collaborators,
+		"share_links.json":   &shareLinks,
+	} {
+		if err := readJSON(dir, name, v); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	report := &RestoreReport{Posts: importer.Import(docs, opts)}
+	if opts.DryRun {
+		report.Comments = len(comments)
+		report.Votes = len(votes)
+		report.AboutInfos = len(about)
+		report.Translations = len(translations)
+		report.Attachments = len(attachments)
+		report.Collaborators = len(collaborators)
+		report.ShareLinks = len(shareLinks)
+		return report, nil
+	}
+
+	// Old IDs in the archive mapped to the rows created by this run, and to
+	// the rows created or updated by it
+	postIDs := make(map[uint]uint)
+	restoredIDs := make(map[uint]uint)
+	for _, item := range report.Posts.Items {
+		if item.Action != importer.ActionCreate && item.Action != importer.ActionUpdate {
+			continue
+		}
+		if oldID, err := strconv.ParseUint(item.SourceID, 10, 32); err == nil {
+			restoredIDs[uint(oldID)] = item.PostID
+			if item.Action == importer.ActionCreate {
+				postIDs[uint(oldID)] = item.PostID
+			}
+		}
+	}
+
+	userIDs := make(map[uint]uint)
+	for _, author := range authors {
+		var user models.User
+		if author.Email != "" && database.DB.Where("email = ?", author.Email).First(&user).Error == nil {
+			userIDs[author.ID] = user.ID
+		}
+	}
+	mapUser := func(id *uint) *uint {
+		if id == nil {
+			return nil
+		}
+		if newID, ok := userIDs[*id]; ok {
+			return &newID
+		}
+		return nil
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, meta := range metas {
+			postID, ok := restoredIDs[meta.ID]
+			if !ok {
+				continue
+			}
+			fields := map[string]interface{}{
+				"protected": meta.Protected,
+				"password":  meta.Password,
+			}
+			if meta.Lang != "" {
+				fields["lang"] = meta.Lang
+			}
+			// Updated posts keep the version the import gave them, so editors
+			// holding an older one still get a conflict
+			if _, created := postIDs[meta.ID]; created && meta.Version > 0 {
+				fields["version"] = meta.Version
+			}
+			if err := tx.Model(&models.Post{}).Where("id = ?", postID).UpdateColumns(fields).Error; err != nil {
+				return err
+			}
+		}
+
+		sort.Slice(comments, func(i, j int) bool { return comments[i].ID < comments[j].ID })
+		commentIDs := make(map[uint]uint)
+		for _, c := range comments {
+			postID, ok := postIDs[c.PostID]
+			if !ok {
+				continue
+			}
+			comment := models.Comment{
+				PostID:    postID,
+				UserID:    mapUser(c.UserID),
+				Content:   c.Content,
+				CreatedAt: c.CreatedAt,
+				UpdatedAt: c.UpdatedAt,
+			}
+			if c.ParentID != nil {
+				if parentID, ok := commentIDs[*c.ParentID]; ok {
+					comment.ParentID = &parentID
+				}
+			}
+			if c.DeletedAt != nil {
+				comment.DeletedAt = gorm.DeletedAt{Time: *c.DeletedAt, Valid: true}
+			}
+			if err := tx.Create(&comment).Error; err != nil {
+				return err
+			}
+			commentIDs[c.ID] = comment.ID
+			report.Comments++
+		}
+
+		for _, v := range votes {
+			commentID, ok := commentIDs[v.CommentID]
+			if !ok {
+				continue
+			}
+			vote := models.Vote{
+				CommentID: commentID,
+				UserID:    mapUser(v.UserID),
+				Emoji:     v.Emoji,
+				CreatedAt: v.CreatedAt,
+			}
+			vote.SetVoter()
+			if err := tx.Create(&vote).Error; err != nil {
+				return err
+			}
+			report.Votes++
+		}
+
+		for _, t := range translations {
+			postID, ok := postIDs[t.PostID]
+			if !ok {
+				continue
+			}
+			translation := models.PostTranslation{
+				PostID:    postID,
+				Lang:      t.Lang,
+				Title:     t.Title,
+				Slug:      t.Slug,
+				Content:   fileLink.ReplaceAllString(t.Content, fmt.Sprintf("${1}%s/api/v1/uploads/%d/", opts.BaseURL, postID)),
+				CreatedAt: t.CreatedAt,
+				UpdatedAt: t.UpdatedAt,
+			}
+			if err := tx.Create(&translation).Error; err != nil {
+				return err
+			}
+			report.Translations++
+		}
+
+		for _, a := range attachments {
+			postID, ok := postIDs[a.PostID]
+			if !ok {
+				continue
+			}
+			attachment := models.PostAttachment{
+				PostID:        postID,
+				Filename:      filepath.Base(a.Filename),
+				Name:          a.Name,
+				Size:          a.Size,
+				MimeType:      a.MimeType,
+				Position:      a.Position,
+				DownloadCount: a.DownloadCount,
+				CreatedAt:     a.CreatedAt,
+			}
+			if err := tx.Create(&attachment).Error; err != nil {
+				return err
+			}
+			report.Attachments++
+		}
+
+		for _, pc := range collaborators {
+			postID, ok := postIDs[pc.PostID]
+			if !ok {
+				continue
+			}
+			userID := mapUser(&pc.UserID)
+			if userID == nil {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("collaborator %d of post %d not found, skipped", pc.UserID, pc.PostID))
+				continue
+			}
+			collaborator := models.PostCollaborator{
+				PostID:     postID,
+				UserID:     *userID,
+				CanEdit:    pc.CanEdit,
+				CanPublish: pc.CanPublish,
+				AcceptedAt: pc.AcceptedAt,
+				CreatedAt:  pc.CreatedAt,
+			}
+			if invitedBy := mapUser(&pc.InvitedBy); invitedBy != nil {
+				collaborator.InvitedBy = *invitedBy
+			}
+			if err := tx.Create(&collaborator).Error; err != nil {
+				return err
+			}
+			report.Collaborators++
+		}
+
+		for _, l := range shareLinks {
+			postID, ok := postIDs[l.PostID]
+			if !ok {
+				continue
+			}
+			// Token hashes are unique, so a link restored before is not copied again
+			var count int64
+			tx.Model(&models.PostShareLink{}).Where("token_hash = ?", l.TokenHash).Count(&count)
+			if count > 0 {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("share link %s... of post %d already exists, skipped", l.TokenHint, l.PostID))
+				continue
+			}
+			link := models.PostShareLink{
+				PostID:        postID,
+				TokenHash:     l.TokenHash,
+				TokenHint:     l.TokenHint,
+				Note:          l.Note,
+				AllowComments: l.AllowComments,
+				ExpiresAt:     l.ExpiresAt,
+				RevokedAt:     l.RevokedAt,
+				CreatedAt:     l.CreatedAt,
+			}
+			if createdBy := mapUser(&l.CreatedBy); createdBy != nil {
+				link.CreatedBy = *createdBy
+			}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+			report.ShareLinks++
+		}
+
+		for _, info := range about {
+			var count int64
+			tx.Model(&models.AboutInfo{}).Where("name = ? AND title = ?", info.Name, info.Title).Count(&count)
+			if count > 0 {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("about info %q already exists, skipped", info.Name))
+				continue
+			}
+			resetAboutInfoIDs(&info)
+			if err := tx.Create(&info).Error; err != nil {
+				return err
+			}
+			report.AboutInfos++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// resetAboutInfoIDs clears the primary keys so the tree is inserted as new rows
+func resetAboutInfoIDs(info *models.AboutInfo) {
+	info.Model = gorm.Model{CreatedAt: info.CreatedAt, UpdatedAt: info.UpdatedAt}
+	for i := range info.Contacts {
+		info.Contacts[i].Model = gorm.Model{}
+		info.Contacts[i].AboutInfoID = 0
+	}
+	for i := range info.Sections {
+		info.Sections[i].Model = gorm.Model{}
+		info.Sections[i].AboutInfoID = 0
+		for j := range info.Sections[i].Items {
+			info.Sections[i].Items[j].Model = gorm.Model{}
+			info.Sections[i].Items[j].SectionID = 0
+		}
+	}
+}
+
+func readJSON(dir, name string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
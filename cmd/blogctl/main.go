@@ -0,0 +1,303 @@
+// Command blogctl runs maintenance tasks against the blog database.
+//
+//	blogctl import [-dry-run] [-fetch-remote] -user <id> <path>
+//	blogctl export [-o blog.zip]
+//	blogctl role -email <email> [-role admin] [-blocked=false]
+//	blogctl purge-trash [-days 30]
+//	blogctl erase-ips [-days 30]
+//	blogctl mail-test -to <email> [-template comment] [-lang ko]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/archive"
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/importer"
+	"github.com/bloomingFlower/blog-backend/mailer"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: blogctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  import   import posts from Markdown files, a Hugo/Jekyll repository, a WordPress WXR export or an export archive")
+	fmt.Fprintln(os.Stderr, "  export   write all posts, files, comments and about info to a zip archive")
+	fmt.Fprintln(os.Stderr, "  role     set the role of a user or block/unblock them")
+	fmt.Fprintln(os.Stderr, "  purge-trash  permanently delete posts that have been in the trash for a number of days")
+	fmt.Fprintln(os.Stderr, "  erase-ips    erase commenter IP addresses older than a number of days, keeping their hashes")
+	fmt.Fprintln(os.Stderr, "  mail-test    send a sample notification email through the configured SMTP server")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "import":
+		runImport(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	case "role":
+		runRole(os.Args[2:])
+	case "purge-trash":
+		runPurgeTrash(os.Args[2:])
+	case "erase-ips":
+		runEraseIPs(os.Args[2:])
+	case "mail-test":
+		runMailTest(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would be imported without writing anything")
+	fetchRemote := fs.Bool("fetch-remote", false, "download images referenced by http(s) URLs")
+	userID := fs.Uint("user", 0, "ID of the author for posts whose author is not a known user")
+	uploadDir := fs.String("uploads", "uploads", "directory holding uploads/<postID>")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: blogctl import [flags] <path>")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	source := fs.Arg(0)
+	if strings.EqualFold(filepath.Ext(source), ".zip") {
+		tmpDir, err := os.MkdirTemp("", "blog-import-")
+		if err != nil {
+			fail(err)
+		}
+		defer os.RemoveAll(tmpDir)
+		if err := importer.ExtractZip(source, tmpDir); err != nil {
+			fail(err)
+		}
+		source = tmpDir
+	}
+
+	opts := importer.Options{
+		DryRun:        *dryRun,
+		DefaultUserID: uint(*userID),
+		FetchRemote:   *fetchRemote,
+		UploadDir:     *uploadDir,
+		BaseURL:       strings.TrimSuffix(os.Getenv("BASE_URL"), "/"),
+	}
+
+	var report *importer.Report
+	var restore *archive.RestoreReport
+	if archive.IsArchive(source) {
+		database.Connect()
+		r, err := archive.Restore(source, opts)
+		if err != nil {
+			fail(err)
+		}
+		report, restore = r.Posts, r
+	} else {
+		docs, err := importer.LoadPath(source)
+		if err != nil {
+			fail(err)
+		}
+		database.Connect()
+		report = importer.Import(docs, opts)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if restore != nil {
+			enc.Encode(restore)
+		} else {
+			enc.Encode(report)
+		}
+	} else {
+		printReport(report)
+		if restore != nil {
+			for _, warning := range restore.Warnings {
+				fmt.Println("warning:", warning)
+			}
+			fmt.Printf("%d comments, %d votes, %d about infos restored\n", restore.Comments, restore.Votes, restore.AboutInfos)
+			fmt.Printf("%d translations, %d attachments, %d collaborators, %d share links restored\n",
+				restore.Translations, restore.Attachments, restore.Collaborators, restore.ShareLinks)
+		}
+	}
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output := fs.String("o", "blog-export.zip", "archive to write, - for stdout")
+	uploadDir := fs.String("uploads", "uploads", "directory holding uploads/<postID>")
+	fs.Parse(args)
+
+	database.Connect()
+
+	w := os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := archive.Export(w, *uploadDir); err != nil {
+		fail(err)
+	}
+}
+
+// runRole sets a user's role, which is how the first admin is created
+func runRole(args []string) {
+	fs := flag.NewFlagSet("role", flag.ExitOnError)
+	email := fs.String("email", "", "email of the user")
+	role := fs.String("role", "", "admin, editor, author or commenter")
+	blocked := fs.String("blocked", "", "true to block the user, false to unblock")
+	fs.Parse(args)
+
+	if *email == "" || (*role == "" && *blocked == "") {
+		fmt.Fprintln(os.Stderr, "usage: blogctl role -email <email> [-role <role>] [-blocked true|false]")
+		fs.PrintDefaults()
+		os.Exit(2)
+	}
+
+	updates := map[string]interface{}{}
+	if *role != "" {
+		if !models.ValidRole(*role) {
+			fail(fmt.Errorf("invalid role %q", *role))
+		}
+		updates["role"] = *role
+	}
+	if *blocked != "" {
+		b, err := strconv.ParseBool(*blocked)
+		if err != nil {
+			fail(fmt.Errorf("invalid -blocked value %q", *blocked))
+		}
+		updates["blocked"] = b
+	}
+
+	database.Connect()
+
+	var user models.User
+	if err := database.DB.Where("email = ?", *email).First(&user).Error; err != nil {
+		fail(fmt.Errorf("user %s: %w", *email, err))
+	}
+	if err := database.DB.Model(&user).Updates(updates).Error; err != nil {
+		fail(err)
+	}
+	database.DB.First(&user, user.ID)
+	fmt.Printf("user %d (%s): role %s, blocked %t\n", user.ID, user.Email, user.Role, user.Blocked)
+}
+
+func runPurgeTrash(args []string) {
+	fs := flag.NewFlagSet("purge-trash", flag.ExitOnError)
+	days := fs.Int("days", int(util.TrashRetention()/(24*time.Hour)), "purge posts deleted more than this many days ago")
+	uploadDir := fs.String("uploads", "uploads", "directory holding uploads/<postID>")
+	fs.Parse(args)
+
+	database.Connect()
+
+	n, err := util.PurgeTrash(database.DB, time.Now().AddDate(0, 0, -*days), *uploadDir)
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("%d posts purged\n", n)
+}
+
+func runEraseIPs(args []string) {
+	fs := flag.NewFlagSet("erase-ips", flag.ExitOnError)
+	days := fs.Int("days", int(util.IPRetention()/(24*time.Hour)), "erase IP addresses recorded more than this many days ago")
+	fs.Parse(args)
+
+	database.Connect()
+
+	n, err := util.EraseIPAddresses(database.DB, time.Now().AddDate(0, 0, -*days))
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("IP addresses of %d records erased\n", n)
+}
+
+func runMailTest(args []string) {
+	fs := flag.NewFlagSet("mail-test", flag.ExitOnError)
+	to := fs.String("to", "", "recipient address")
+	name := fs.String("template", mailer.TemplateComment, "template to send: comment or reply")
+	lang := fs.String("lang", "", "template language: ko or en (default MAIL_LANGUAGE)")
+	fs.Parse(args)
+
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "usage: blogctl mail-test -to <email> [-template comment|reply] [-lang ko|en]")
+		os.Exit(2)
+	}
+	queue := mailer.FromEnv(nil)
+	if queue == nil {
+		fail(errors.New("SMTP_HOST is not set"))
+	}
+
+	baseURL := strings.TrimSuffix(os.Getenv("BASE_URL"), "/")
+	message, err := mailer.Render(*name, *lang, mailer.CommentNotice{
+		RecipientName:  "blogctl",
+		ActorName:      "blogctl",
+		PostTitle:      "Test",
+		Excerpt:        "This is a test notification.",
+		URL:            baseURL + "/",
+		UnsubscribeURL: baseURL + "/",
+	})
+	if err != nil {
+		fail(err)
+	}
+	message.To = *to
+	if err := queue.Sender.Send(context.Background(), message); err != nil {
+		fail(err)
+	}
+	fmt.Printf("test email sent to %s\n", *to)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
+
+func printReport(report *importer.Report) {
+	for _, item := range report.Items {
+		fmt.Printf("%-9s %s:%s %q", item.Action, item.Source, item.SourceID, item.Title)
+		if item.PostID != 0 {
+			fmt.Printf(" -> post %d", item.PostID)
+		}
+		if item.Images > 0 {
+			fmt.Printf(" (%d images)", item.Images)
+		}
+		fmt.Println()
+		for _, warning := range item.Warnings {
+			fmt.Println("          warning:", warning)
+		}
+		if item.Error != "" {
+			fmt.Println("          error:", item.Error)
+		}
+	}
+
+	mode := ""
+	if report.DryRun {
+		mode = " (dry run)"
+	}
+	fmt.Printf("\n%d created, %d updated, %d unchanged, %d failed%s\n",
+		report.Created, report.Updated, report.Unchanged, report.Failed, mode)
+}
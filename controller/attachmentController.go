@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const maxAttachments = 20
+
+// saveAttachments stores every file part of the request as an attachment of
+// the post, after the existing ones. If one file is rejected none are kept.
+func saveAttachments(c *fiber.Ctx, post *models.Post) ([]models.PostAttachment, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		// 파일 없이 보낸 요청
+		return nil, nil
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var count int64
+	database.DB.Model(&models.PostAttachment{}).Where("post_id = ?", post.ID).Count(&count)
+	if int(count)+len(files) > maxAttachments {
+		return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("a post can have at most %d attachments", maxAttachments))
+	}
+
+	dirPath := fmt.Sprintf("uploads/%d", post.ID)
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return nil, err
+	}
+
+	var position int
+	database.DB.Model(&models.PostAttachment{}).Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(position), -1) + 1").Scan(&position)
+
+	attachments := make([]models.PostAttachment, 0, len(files))
+	removeSaved := func() {
+		for _, attachment := range attachments {
+			os.Remove(filepath.Join(dirPath, attachment.Filename))
+		}
+	}
+	for i, file := range files {
+		filename, err := saveUploadedFile(c, file, dirPath)
+		if err != nil {
+			removeSaved()
+			return nil, err
+		}
+		attachments = append(attachments, models.PostAttachment{
+			PostID:   post.ID,
+			Filename: filename,
+			Name:     filepath.Base(file.Filename),
+			Size:     file.Size,
+			MimeType: mime.TypeByExtension(filepath.Ext(file.Filename)),
+			Position: position + i,
+		})
+	}
+	if err := database.DB.Create(&attachments).Error; err != nil {
+		removeSaved()
+		return nil, err
+	}
+
+	syncPostFile(post)
+	return attachments, nil
+}
+
+// syncPostFile points Post.File at the first attachment for clients that
+// only know the single file field
+func syncPostFile(post *models.Post) {
+	var first models.PostAttachment
+	file := ""
+	if database.DB.Where("post_id = ?", post.ID).Order("position, id").First(&first).Error == nil {
+		file = fmt.Sprintf("files/%d/%s", post.ID, first.Filename)
+	}
+	if file != post.File {
+		post.File = file
+		database.DB.Model(post).UpdateColumn("file", file)
+	}
+}
+
+// attachmentError answers a failed upload, keeping the message of
+// validation errors from saveUploadedFile
+func attachmentError(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"message": fiberErr.Message,
+		})
+	}
+	log.Error("--> AttachmentController: Failed to save attachments: ", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"message": "Could not save file",
+	})
+}
+
+// findEditablePost loads the post from the :id param and checks that the
+// current user may update it. It writes the error response and returns
+// false otherwise.
+func findEditablePost(c *fiber.Ctx) (models.Post, bool) {
+	var post models.Post
+	id, ok := postIDParam(c)
+	if !ok {
+		return post, false
+	}
+	if err := database.DB.First(&post, id).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+		return post, false
+	}
+	if !canEditPost(middleware.CurrentUser(c), post) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to update this post",
+		})
+		return post, false
+	}
+	return post, true
+}
+
+// GetAttachments lists the attachments of a post in display order
+func GetAttachments(c *fiber.Ctx) error {
+	var post models.Post
+	id, _ := c.ParamsInt("id")
+	if err := viewablePosts(c, uint(id), false).Where("id = ?", id).First(&post).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+
+	if !canReadPost(c, post) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "Post is protected",
+		})
+	}
+
+	var attachments []models.PostAttachment
+	if err := database.DB.Where("post_id = ?", post.ID).Order("position, id").Find(&attachments).Error; err != nil {
+		log.Error("--> AttachmentController: GetAttachments: Failed to get attachments: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching attachments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": attachments,
+	})
+}
+
+// AddAttachments uploads one or more file parts to a post
+func AddAttachments(c *fiber.Ctx) error {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return nil
+	}
+
+	attachments, err := saveAttachments(c, &post)
+	if err != nil {
+		return attachmentError(c, err)
+	}
+	if len(attachments) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "file is required",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data":    attachments,
+		"message": "Attachments added successfully",
+	})
+}
+
+// ReorderAttachments sets the display order of the attachments of a post.
+// The body lists every attachment ID in the new order.
+func ReorderAttachments(c *fiber.Ctx) error {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return nil
+	}
+
+	var data struct {
+		IDs []uint `json:"ids"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+
+	var attachments []models.PostAttachment
+	database.DB.Where("post_id = ?", post.ID).Find(&attachments)
+	existing := make(map[uint]bool, len(attachments))
+	for _, attachment := range attachments {
+		existing[attachment.ID] = true
+	}
+	if len(data.IDs) != len(attachments) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "ids must list every attachment of the post",
+		})
+	}
+	for _, id := range data.IDs {
+		if !existing[id] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": fmt.Sprintf("attachment %d does not belong to the post", id),
+			})
+		}
+		delete(existing, id)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for position, id := range data.IDs {
+			if err := tx.Model(&models.PostAttachment{}).Where("id = ?", id).UpdateColumn("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("--> AttachmentController: ReorderAttachments: Failed to reorder attachments: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to reorder attachments",
+		})
+	}
+	syncPostFile(&post)
+
+	return c.JSON(fiber.Map{
+		"message": "Attachments reordered successfully",
+	})
+}
+
+// UpdateAttachment renames the display name of an attachment
+func UpdateAttachment(c *fiber.Ctx) error {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return nil
+	}
+
+	var attachment models.PostAttachment
+	if err := database.DB.Where("id = ? AND post_id = ?", c.Params("attachmentId"), post.ID).First(&attachment).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Attachment not found",
+		})
+	}
+
+	var data struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+	name := strings.TrimSpace(data.Name)
+	if name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "name is required",
+		})
+	}
+
+	if err := database.DB.Model(&attachment).UpdateColumn("name", name).Error; err != nil {
+		log.Error("--> AttachmentController: UpdateAttachment: Failed to update attachment: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to update attachment",
+		})
+	}
+	attachment.Name = name
+
+	return c.JSON(fiber.Map{
+		"data":    attachment,
+		"message": "Attachment updated successfully",
+	})
+}
+
+// DeleteAttachment removes an attachment and its file
+func DeleteAttachment(c *fiber.Ctx) error {
+	post, ok := findEditablePost(c)
+	if !ok {
+		return nil
+	}
+
+	var attachment models.PostAttachment
+	if err := database.DB.Where("id = ? AND post_id = ?", c.Params("attachmentId"), post.ID).First(&attachment).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Attachment not found",
+		})
+	}
+
+	if err := database.DB.Delete(&attachment).Error; err != nil {
+		log.Error("--> AttachmentController: DeleteAttachment: Failed to delete attachment: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to delete attachment",
+		})
+	}
+	path := filepath.Join("uploads", fmt.Sprint(post.ID), attachment.Filename)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Error("--> AttachmentController: DeleteAttachment: Failed to delete file: ", err)
+	}
+	syncPostFile(&post)
+
+	return c.JSON(fiber.Map{
+		"message": "Attachment deleted successfully",
+	})
+}
+
+// DownloadAttachment sends an attachment under its display name and counts the download
+func DownloadAttachment(c *fiber.Ctx) error {
+	var post models.Post
+	id, _ := c.ParamsInt("id")
+	if err := viewablePosts(c, uint(id), false).Where("id = ?", id).First(&post).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+
+	if !canReadPost(c, post) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "Post is protected",
+		})
+	}
+
+	var attachment models.PostAttachment
+	if err := database.DB.Where("id = ? AND post_id = ?", c.Params("attachmentId"), post.ID).First(&attachment).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Attachment not found",
+		})
+	}
+
+	path := filepath.Join("uploads", fmt.Sprint(post.ID), attachment.Filename)
+	if _, err := os.Stat(path); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "File not found",
+		})
+	}
+
+	database.DB.Model(&attachment).UpdateColumn("download_count", gorm.Expr("download_count + 1"))
+
+	c.Attachment(attachment.Name)
+	return c.SendFile(path)
+}
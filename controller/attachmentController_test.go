@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSaveUploadedFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		want     string // 저장된 파일 이름의 임의 접두어 뒤 부분
+		err      string
+	}{
+		{"text", "notes.txt", "notes.txt", ""},
+		{"image", "photo.png", "photo.png", ""},
+		{"path in name", "../../etc/evil.txt", "evil.txt", ""},
+		{"executable", "setup.exe", "", "unsupported extension"},
+		{"no extension", "README", "", "unsupported extension"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			app := fiber.New()
+			var saved string
+			var saveErr error
+			app.Post("/", func(c *fiber.Ctx) error {
+				file, err := c.FormFile("file")
+				if err != nil {
+					return err
+				}
+				saved, saveErr = saveUploadedFile(c, file, dir)
+				return nil
+			})
+
+			var body bytes.Buffer
+			w := multipart.NewWriter(&body)
+			part, _ := w.CreateFormFile("file", tt.filename)
+			part.Write([]byte("content"))
+			w.Close()
+			req := httptest.NewRequest("POST", "/", &body)
+			req.Header.Set("Content-Type", w.FormDataContentType())
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.err != "" {
+				var fiberErr *fiber.Error
+				if !errors.As(saveErr, &fiberErr) || fiberErr.Code != fiber.StatusBadRequest || fiberErr.Message != tt.err {
+					t.Errorf("saveUploadedFile error = %v, want 400 %q", saveErr, tt.err)
+				}
+				return
+			}
+			if saveErr != nil {
+				t.Fatal(saveErr)
+			}
+			if len(saved) != 9+len(tt.want) || !strings.HasSuffix(saved, "-"+tt.want) {
+				t.Errorf("filename = %q, want a random prefix and %q", saved, tt.want)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, saved))
+			if err != nil || string(content) != "content" {
+				t.Errorf("saved file = %q, %v", content, err)
+			}
+		})
+	}
+}
+
+func TestAttachmentError(t *testing.T) {
+	tests := []struct {
+		err     error
+		status  int
+		message string
+	}{
+		{fiber.NewError(fiber.StatusBadRequest, "unsupported extension"), fiber.StatusBadRequest, "unsupported extension"},
+		// 내부 오류는 자세한 내용을 숨긴다
+		{errors.New("disk full"), fiber.StatusInternalServerError, "Could not save file"},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			return attachmentError(c, tt.err)
+		})
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if resp.StatusCode != tt.status || body.Message != tt.message {
+			t.Errorf("attachmentError(%v) = %d %q, want %d %q", tt.err, resp.StatusCode, body.Message, tt.status, tt.message)
+		}
+	}
+}
@@ -12,26 +12,38 @@ import (
 	"github.com/dgrijalva/jwt-go"
 
 	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
 	"github.com/bloomingFlower/blog-backend/models"
 	"github.com/gofiber/fiber/v2"
 )
 
-// comment 인증 처리
-type UserClaims struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Picture string `json:"picture"`
-	Admin   bool   `json:"admin"`
-	Blocked bool   `json:"blocked"`
-	jwt.StandardClaims
-}
-
 // TODO: email domain 체크
 func validateEmail(email string) bool {
 	re := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
 	return re.MatchString(email)
 }
 
+// setUsername sets the name the user is @mentioned with, after checking that
+// it is valid and not taken. An empty name removes it. It returns the error
+// message for the response, or "".
+func setUsername(user *models.User, name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+	if name == "" {
+		user.Username = nil
+		return ""
+	}
+	if !validUsername(name) {
+		return "Username must be 3 to 32 letters, digits or underscores"
+	}
+	var count int64
+	database.DB.Model(&models.User{}).Where("username = ? AND id <> ?", name, user.ID).Count(&count)
+	if count > 0 {
+		return "Username already exists"
+	}
+	user.Username = &name
+	return ""
+}
+
 // TODO: google, github, kakao, naver 로그인
 // web3 AA 로그인
 func Register(c *fiber.Ctx) error {
@@ -69,6 +81,15 @@ func Register(c *fiber.Ctx) error {
 		Email:     strings.TrimSpace(data["email"].(string)),
 		Password:  []byte(data["password"].(string)),
 		Phone:     data["phone"].(string),
+		Role:      models.RoleCommenter,
+	}
+	if name, ok := data["username"].(string); ok {
+		if message := setUsername(&user, name); message != "" {
+			c.Status(http.StatusBadRequest)
+			return c.JSON(fiber.Map{
+				"message": message,
+			})
+		}
 	}
 
 	user.SetPassword(data["password"].(string))
@@ -102,6 +123,12 @@ func Login(c *fiber.Ctx) error {
 			"message": "Incorrect password",
 		})
 	}
+	if user.Blocked {
+		c.Status(http.StatusForbidden)
+		return c.JSON(fiber.Map{
+			"message": "User is blocked",
+		})
+	}
 	token, err := util.GenerateJwt(user.ID)
 	if err != nil {
 		c.Status(http.StatusInternalServerError)
@@ -167,6 +194,13 @@ func UpdateUser(c *fiber.Ctx) error {
 			"message": "User not found",
 		})
 	}
+	// Users can only update themselves unless they manage users
+	if current := middleware.CurrentUser(c); user.ID != current.ID && !current.Can(models.PermManageUsers) {
+		c.Status(http.StatusForbidden)
+		return c.JSON(fiber.Map{
+			"message": "You don't have permission to update this user",
+		})
+	}
 	// Update the user's information
 	if data["first_name"] != nil {
 		user.FirstName = data["first_name"].(string)
@@ -180,6 +214,14 @@ func UpdateUser(c *fiber.Ctx) error {
 	if data["phone"] != nil {
 		user.Phone = data["phone"].(string)
 	}
+	if name, ok := data["username"].(string); ok {
+		if message := setUsername(&user, name); message != "" {
+			c.Status(http.StatusBadRequest)
+			return c.JSON(fiber.Map{
+				"message": message,
+			})
+		}
+	}
 	if data["password"] != nil {
 		user.SetPassword(data["password"].(string))
 	}
@@ -197,6 +239,61 @@ func UpdateUser(c *fiber.Ctx) error {
 	})
 }
 
+// UpdateUserRole changes the role of a user and blocks or unblocks them
+func UpdateUserRole(c *fiber.Ctx) error {
+	var data struct {
+		Role    *string `json:"role"`
+		Blocked *bool   `json:"blocked"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		c.Status(http.StatusBadRequest)
+		return c.JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+
+	var user models.User
+	database.DB.Where("id = ?", c.Params("id")).First(&user)
+	if user.ID == 0 {
+		c.Status(http.StatusNotFound)
+		return c.JSON(fiber.Map{
+			"message": "User not found",
+		})
+	}
+	if user.ID == middleware.CurrentUser(c).ID {
+		c.Status(http.StatusBadRequest)
+		return c.JSON(fiber.Map{
+			"message": "You can't change your own role",
+		})
+	}
+
+	if data.Role != nil {
+		if !models.ValidRole(*data.Role) {
+			c.Status(http.StatusBadRequest)
+			return c.JSON(fiber.Map{
+				"message": "Invalid role",
+			})
+		}
+		user.Role = *data.Role
+	}
+	if data.Blocked != nil {
+		user.Blocked = *data.Blocked
+	}
+
+	if err := database.DB.Model(&user).Select("Role", "Blocked").Updates(&user).Error; err != nil {
+		log.Println(err)
+		c.Status(http.StatusInternalServerError)
+		return c.JSON(fiber.Map{
+			"message": "Unable to update user",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"user":    user,
+		"message": "User role updated successfully",
+	})
+}
+
 type Claims struct {
 	jwt.StandardClaims
 }
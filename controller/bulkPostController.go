@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const maxBulkPosts = 500
+
+// Bulk actions
+const (
+	bulkHide      = "hide"
+	bulkUnhide    = "unhide"
+	bulkDelete    = "delete"
+	bulkCategory  = "category"
+	bulkAddTag    = "add_tag"
+	bulkRemoveTag = "remove_tag"
+	bulkStatus    = "status"
+)
+
+// Post statuses accepted by the status action
+const (
+	statusPublished = "published"
+	statusHidden    = "hidden"
+	statusTrashed   = "trashed"
+)
+
+// Per-item results
+const (
+	bulkOK        = "ok"
+	bulkUnchanged = "unchanged"
+	bulkNotFound  = "not_found"
+	bulkForbidden = "forbidden"
+	bulkConflict  = "conflict"
+	bulkFailed    = "failed"
+)
+
+type bulkPostFilter struct {
+	Category string `json:"category"`
+	Tag      string `json:"tag"`
+	Query    string `json:"query"`
+	Hidden   *bool  `json:"hidden"`
+}
+
+type bulkPostRequest struct {
+	Action string          `json:"action"`
+	Value  string          `json:"value"`
+	IDs    []uint          `json:"ids"`
+	Filter *bulkPostFilter `json:"filter"`
+}
+
+type bulkPostResult struct {
+	ID      uint   `json:"id"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+var errBulkConflict = errors.New("the post was changed by someone else")
+
+// validate checks the action and its value
+func (r *bulkPostRequest) validate() string {
+	switch r.Action {
+	case bulkHide, bulkUnhide, bulkDelete:
+	case bulkCategory:
+		if strings.TrimSpace(r.Value) == "" {
+			return "value is required for category"
+		}
+	case bulkAddTag, bulkRemoveTag:
+		if strings.TrimSpace(r.Value) == "" || strings.Contains(r.Value, ",") {
+			return "value must be a single tag"
+		}
+	case bulkStatus:
+		switch r.Value {
+		case statusPublished, statusHidden, statusTrashed:
+		default:
+			return "value must be published, hidden or trashed"
+		}
+	default:
+		return "unknown action"
+	}
+	if len(r.IDs) == 0 && r.Filter == nil {
+		return "ids or filter is required"
+	}
+	if len(r.IDs) > maxBulkPosts {
+		return "too many posts"
+	}
+	return ""
+}
+
+// BulkPosts applies one action to a list of posts or to the posts matching a
+// filter. Every post is checked like UpdatePost and changed in its own
+// savepoint, so a failing post does not undo the others.
+func BulkPosts(c *fiber.Ctx) error {
+	var req bulkPostRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+	req.Value = strings.TrimSpace(req.Value)
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": msg,
+		})
+	}
+
+	user := middleware.CurrentUser(c)
+	query := visiblePosts(database.DB.Model(&models.Post{}), user)
+	if len(req.IDs) > 0 {
+		query = query.Where("id IN ?", req.IDs)
+	} else {
+		// 필터만 주면 바꿀 수 있는 포스트 중에서 고름
+		query = bulkActionPosts(query, user, req)
+	}
+	if f := req.Filter; f != nil {
+		if f.Category != "" {
+			query = query.Where("category = ?", f.Category)
+		}
+		if f.Tag != "" {
+			query = query.Where("FIND_IN_SET(?, tags) > 0", f.Tag)
+		}
+		if f.Query != "" {
+			like := "%" + f.Query + "%"
+			query = query.Where("(title LIKE ? OR content LIKE ?)", like, like)
+		}
+		if f.Hidden != nil {
+			query = query.Where("hidden = ?", *f.Hidden)
+		}
+	}
+
+	var posts []models.Post
+	if err := query.Order("id").Limit(maxBulkPosts + 1).Find(&posts).Error; err != nil {
+		log.Error("--> BulkPostController: BulkPosts: Failed to get posts: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching posts",
+		})
+	}
+	if len(posts) > maxBulkPosts {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "The filter matches too many posts",
+		})
+	}
+
+	results := []bulkPostResult{}
+	found := make(map[uint]bool)
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, post := range posts {
+			found[post.ID] = true
+			result := bulkPostResult{ID: post.ID, Status: bulkOK}
+
+			if !canApplyBulkAction(user, post, req) {
+				result.Status = bulkForbidden
+				results = append(results, result)
+				continue
+			}
+
+			changed := false
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				var err error
+				changed, err = applyBulkAction(tx, post, req)
+				return err
+			})
+			switch {
+			case errors.Is(err, errBulkConflict):
+				result.Status = bulkConflict
+				result.Message = err.Error()
+			case err != nil:
+				log.Error("--> BulkPostController: BulkPosts: Failed to update post: ", err)
+				result.Status = bulkFailed
+				result.Message = "Error updating post"
+			case !changed:
+				result.Status = bulkUnchanged
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("--> BulkPostController: BulkPosts: Transaction failed: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error updating posts",
+		})
+	}
+
+	for _, id := range req.IDs {
+		if !found[id] {
+			found[id] = true
+			results = append(results, bulkPostResult{ID: id, Status: bulkNotFound})
+		}
+	}
+
+	summary := make(map[string]int)
+	for _, result := range results {
+		summary[result.Status]++
+	}
+	return c.JSON(fiber.Map{
+		"data": results,
+		"meta": summary,
+	})
+}
+
+// bulkActionPosts restricts query to the posts canApplyBulkAction allows
+func bulkActionPosts(query *gorm.DB, user *models.User, req bulkPostRequest) *gorm.DB {
+	if user.Can(models.PermEditAnyPost) {
+		return query
+	}
+	right := "can_edit"
+	switch {
+	case req.Action == bulkDelete, req.Action == bulkStatus && req.Value == statusTrashed:
+		return query.Where("posts.user_id = ?", user.ID)
+	case req.Action == bulkHide, req.Action == bulkUnhide, req.Action == bulkStatus:
+		right = "can_publish"
+	}
+	collaborations := database.DB.Model(&models.PostCollaborator{}).Select("post_id").
+		Where("user_id = ? AND accepted_at IS NOT NULL", user.ID).Where(right+" = ?", true)
+	return query.Where("(posts.user_id = ? OR posts.id IN (?))", user.ID, collaborations)
+}
+
+// canApplyBulkAction checks the same permissions as the single post endpoints
+func canApplyBulkAction(user *models.User, post models.Post, req bulkPostRequest) bool {
+	switch {
+	case req.Action == bulkDelete, req.Action == bulkStatus && req.Value == statusTrashed:
+		return canManagePost(user, post)
+	case req.Action == bulkHide, req.Action == bulkUnhide, req.Action == bulkStatus:
+		return canPublishPost(user, post)
+	default:
+		return canEditPost(user, post)
+	}
+}
+
+// applyBulkAction changes one post and reports whether anything changed
+func applyBulkAction(tx *gorm.DB, post models.Post, req bulkPostRequest) (bool, error) {
+	if req.Action == bulkDelete || req.Action == bulkStatus && req.Value == statusTrashed {
+		result := tx.Where("version = ?", post.Version).Delete(&post)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return false, errBulkConflict
+		}
+		return true, result.Error
+	}
+
+	updates := map[string]interface{}{}
+	switch req.Action {
+	case bulkHide, bulkUnhide, bulkStatus:
+		hidden := req.Action == bulkHide || req.Value == statusHidden
+		if post.Hidden != hidden {
+			updates["hidden"] = hidden
+		}
+	case bulkCategory:
+		if post.Category != req.Value {
+			updates["category"] = req.Value
+		}
+	case bulkAddTag, bulkRemoveTag:
+		if tags, changed := changeTag(post.Tags, req.Value, req.Action == bulkAddTag); changed {
+			updates["tags"] = tags
+		}
+	}
+	if len(updates) == 0 {
+		return false, nil
+	}
+
+	// 내용이 바뀌는 작업은 UpdatePost처럼 수정 시각 갱신
+	if req.Action == bulkCategory || req.Action == bulkAddTag || req.Action == bulkRemoveTag {
+		updates["updated_at"] = time.Now()
+	}
+	updates["version"] = post.Version + 1
+	result := tx.Model(&post).Where("version = ?", post.Version).UpdateColumns(updates)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return false, errBulkConflict
+	}
+	return true, result.Error
+}
+
+// changeTag adds tag to or removes it from a comma separated tag list and
+// reports whether the list changed
+func changeTag(tags, tag string, add bool) (string, bool) {
+	list := []string{}
+	present := false
+	for _, t := range strings.Split(tags, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if t == tag {
+			present = true
+			if !add {
+				continue
+			}
+		}
+		list = append(list, t)
+	}
+	if add && !present {
+		list = append(list, tag)
+	}
+	return strings.Join(list, ","), add != present
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"testing"
+)
+
+func TestBulkPostRequestValidate(t *testing.T) {
+	ids := []uint{1, 2}
+	tooMany := make([]uint, maxBulkPosts+1)
+	tests := []struct {
+		name string
+		req  bulkPostRequest
+		want string
+	}{
+		{"hide", bulkPostRequest{Action: bulkHide, IDs: ids}, ""},
+		{"delete by filter", bulkPostRequest{Action: bulkDelete, Filter: &bulkPostFilter{Category: "old"}}, ""},
+		{"unknown action", bulkPostRequest{Action: "archive", IDs: ids}, "unknown action"},
+		{"category without value", bulkPostRequest{Action: bulkCategory, Value: " ", IDs: ids}, "value is required for category"},
+		{"category", bulkPostRequest{Action: bulkCategory, Value: "notes", IDs: ids}, ""},
+		{"tag list", bulkPostRequest{Action: bulkAddTag, Value: "go,web", IDs: ids}, "value must be a single tag"},
+		{"empty tag", bulkPostRequest{Action: bulkRemoveTag, IDs: ids}, "value must be a single tag"},
+		{"tag", bulkPostRequest{Action: bulkRemoveTag, Value: "go", IDs: ids}, ""},
+		{"unknown status", bulkPostRequest{Action: bulkStatus, Value: "draft", IDs: ids}, "value must be published, hidden or trashed"},
+		{"status", bulkPostRequest{Action: bulkStatus, Value: statusTrashed, IDs: ids}, ""},
+		{"no posts", bulkPostRequest{Action: bulkHide}, "ids or filter is required"},
+		{"too many posts", bulkPostRequest{Action: bulkHide, IDs: tooMany}, "too many posts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.req.validate(); got != tt.want {
+				t.Errorf("validate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeTag(t *testing.T) {
+	tests := []struct {
+		tags    string
+		tag     string
+		add     bool
+		want    string
+		changed bool
+	}{
+		{"", "go", true, "go", true},
+		{"go,web", "go", true, "go,web", false},
+		{" go , web ,", "rust", true, "go,web,rust", true},
+		{"go,web", "go", false, "web", true},
+		{"go,web", "rust", false, "go,web", false},
+		{"go,go", "go", false, "", true},
+		{"Go", "go", false, "Go", false},
+	}
+	for _, tt := range tests {
+		name := "remove"
+		if tt.add {
+			name = "add"
+		}
+		got, changed := changeTag(tt.tags, tt.tag, tt.add)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("%s %q to %q = %q, %t, want %q, %t", name, tt.tag, tt.tags, got, changed, tt.want, tt.changed)
+		}
+	}
+}
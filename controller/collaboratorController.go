@@ -0,0 +1,404 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// postCollaborator returns the accepted collaboration of the user on the
+// post, or nil
+func postCollaborator(postID uint, user *models.User) *models.PostCollaborator {
+	if user == nil {
+		return nil
+	}
+	var collaborator models.PostCollaborator
+	err := database.DB.Where("post_id = ? AND user_id = ? AND accepted_at IS NOT NULL", postID, user.ID).
+		First(&collaborator).Error
+	if err != nil {
+		return nil
+	}
+	return &collaborator
+}
+
+// postAuthor keeps only the public profile of a post author
+func postAuthor(user models.User) models.User {
+	return models.User{
+		ID:        user.ID,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Picture:   user.Picture,
+	}
+}
+
+// selectPostAuthor preloads only the fields kept by postAuthor
+func selectPostAuthor(db *gorm.DB) *gorm.DB {
+	return db.Select("ID", "Username", "FirstName", "LastName", "Picture")
+}
+
+// loadPostAuthors fills Authors with the owner followed by the accepted
+// collaborators of each post, using a single query for all posts
+func loadPostAuthors(posts []models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+	postIDs := make([]uint, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	var collaborators []models.PostCollaborator
+	database.DB.Preload("User", selectPostAuthor).
+		Where("post_id IN ? AND accepted_at IS NOT NULL", postIDs).
+		Order("accepted_at").
+		Find(&collaborators)
+
+	byPost := make(map[uint][]models.User)
+	for _, collaborator := range collaborators {
+		byPost[collaborator.PostID] = append(byPost[collaborator.PostID], collaborator.User)
+	}
+	for i := range posts {
+		posts[i].Authors = append([]models.User{postAuthor(posts[i].User)}, byPost[posts[i].ID]...)
+	}
+}
+
+// authorNames joins the names of the post authors for display
+func authorNames(post models.Post) string {
+	authors := post.Authors
+	if len(authors) == 0 {
+		authors = []models.User{post.User}
+	}
+	names := make([]string, 0, len(authors))
+	for _, author := range authors {
+		if name := strings.TrimSpace(author.FirstName + " " + author.LastName); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// findManagedPost loads the post from the :id param and checks that the
+// current user may manage its collaborators. It writes the error response
+// and returns false otherwise.
+func findManagedPost(c *fiber.Ctx) (models.Post, bool) {
+	var post models.Post
+	id, ok := postIDParam(c)
+	if !ok {
+		return post, false
+	}
+	if err := database.DB.First(&post, id).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+		return post, false
+	}
+	if !canManagePost(middleware.CurrentUser(c), post) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to manage collaborators of this post",
+		})
+		return post, false
+	}
+	return post, true
+}
+
+// GetCollaborators lists the collaborators and pending invitations of a post
+func GetCollaborators(c *fiber.Ctx) error {
+	post, ok := findManagedPost(c)
+	if !ok {
+		return nil
+	}
+
+	var collaborators []models.PostCollaborator
+	if err := database.DB.Preload("User", selectPostAuthor).Where("post_id = ?", post.ID).Order("created_at").Find(&collaborators).Error; err != nil {
+		log.Error("--> CollaboratorController: GetCollaborators: Failed to get collaborators: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching collaborators",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": collaborators,
+	})
+}
+
+// InviteCollaborator invites a user, by ID or email, to collaborate on a post
+func InviteCollaborator(c *fiber.Ctx) error {
+	post, ok := findManagedPost(c)
+	if !ok {
+		return nil
+	}
+
+	var data struct {
+		UserID     uint   `json:"user_id"`
+		Email      string `json:"email"`
+		CanEdit    *bool  `json:"can_edit"`
+		CanPublish bool   `json:"can_publish"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+
+	// 이메일로 초대할 때는 가입 여부가 드러나지 않도록 항상 같은 응답을 보냄
+	byEmail := data.UserID == 0 && data.Email != ""
+	var invitee models.User
+	switch {
+	case data.UserID != 0:
+		database.DB.First(&invitee, data.UserID)
+	case data.Email != "":
+		database.DB.Where("email = ?", strings.TrimSpace(data.Email)).First(&invitee)
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "user_id or email is required",
+		})
+	}
+	if byEmail && (invitee.ID == 0 || invitee.ID == post.UserID || invitee.Blocked) {
+		return invitationSent(c)
+	}
+	if invitee.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "User not found",
+		})
+	}
+	if invitee.ID == post.UserID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "The post author can't be invited",
+		})
+	}
+	if invitee.Blocked {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "User is blocked",
+		})
+	}
+
+	var count int64
+	database.DB.Model(&models.PostCollaborator{}).Where("post_id = ? AND user_id = ?", post.ID, invitee.ID).Count(&count)
+	if count > 0 && byEmail {
+		return invitationSent(c)
+	}
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"message": "User is already invited",
+		})
+	}
+
+	// 편집 권한은 기본으로 부여
+	canEdit := true
+	if data.CanEdit != nil {
+		canEdit = *data.CanEdit
+	}
+	collaborator := models.PostCollaborator{
+		PostID:     post.ID,
+		UserID:     invitee.ID,
+		InvitedBy:  middleware.CurrentUser(c).ID,
+		CanEdit:    canEdit,
+		CanPublish: data.CanPublish,
+	}
+	if err := database.DB.Create(&collaborator).Error; err != nil {
+		log.Error("--> CollaboratorController: InviteCollaborator: Failed to create invitation: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to invite collaborator",
+		})
+	}
+	if byEmail {
+		return invitationSent(c)
+	}
+	collaborator.User = postAuthor(invitee)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data":    collaborator,
+		"message": "Collaborator invited successfully",
+	})
+}
+
+// invitationSent is the response to every invitation by email, so that it
+// doesn't tell whether the address belongs to a user
+func invitationSent(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "If a user with this email exists, they have been invited",
+	})
+}
+
+// UpdateCollaborator changes the permissions of a collaborator
+func UpdateCollaborator(c *fiber.Ctx) error {
+	post, ok := findManagedPost(c)
+	if !ok {
+		return nil
+	}
+
+	var collaborator models.PostCollaborator
+	if err := database.DB.Preload("User", selectPostAuthor).Where("post_id = ? AND user_id = ?", post.ID, c.Params("userId")).First(&collaborator).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Collaborator not found",
+		})
+	}
+
+	var data struct {
+		CanEdit    *bool `json:"can_edit"`
+		CanPublish *bool `json:"can_publish"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+	if data.CanEdit != nil {
+		collaborator.CanEdit = *data.CanEdit
+	}
+	if data.CanPublish != nil {
+		collaborator.CanPublish = *data.CanPublish
+	}
+
+	if err := database.DB.Model(&collaborator).Select("CanEdit", "CanPublish").Updates(&collaborator).Error; err != nil {
+		log.Error("--> CollaboratorController: UpdateCollaborator: Failed to update collaborator: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to update collaborator",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":    collaborator,
+		"message": "Collaborator updated successfully",
+	})
+}
+
+// RemoveCollaborator removes a collaborator or withdraws an invitation.
+// Collaborators can also remove themselves.
+func RemoveCollaborator(c *fiber.Ctx) error {
+	id, ok := postIDParam(c)
+	if !ok {
+		return nil
+	}
+	var post models.Post
+	if err := database.DB.First(&post, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+
+	userID, err := strconv.ParseUint(c.Params("userId"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid user ID",
+		})
+	}
+	user := middleware.CurrentUser(c)
+	if uint(userID) != user.ID && !canManagePost(user, post) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to manage collaborators of this post",
+		})
+	}
+
+	result := database.DB.Where("post_id = ? AND user_id = ?", post.ID, userID).Delete(&models.PostCollaborator{})
+	if result.Error != nil {
+		log.Error("--> CollaboratorController: RemoveCollaborator: Failed to remove collaborator: ", result.Error)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to remove collaborator",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Collaborator not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Collaborator removed successfully",
+	})
+}
+
+// GetInvitations lists the pending collaboration invitations of the current user
+func GetInvitations(c *fiber.Ctx) error {
+	var invitations []struct {
+		models.PostCollaborator
+		PostTitle string `json:"post_title"`
+	}
+	err := database.DB.Model(&models.PostCollaborator{}).
+		Select("post_collaborators.*, posts.title as post_title").
+		Joins("JOIN posts ON posts.id = post_collaborators.post_id").
+		Where("post_collaborators.user_id = ? AND post_collaborators.accepted_at IS NULL AND posts.deleted_at IS NULL", middleware.CurrentUser(c).ID).
+		Order("post_collaborators.created_at DESC").
+		Find(&invitations).Error
+	if err != nil {
+		log.Error("--> CollaboratorController: GetInvitations: Failed to get invitations: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching invitations",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": invitations,
+	})
+}
+
+// findInvitation loads the pending invitation of the current user for the
+// :id post. It writes the error response and returns false otherwise.
+func findInvitation(c *fiber.Ctx) (models.PostCollaborator, bool) {
+	var invitation models.PostCollaborator
+	err := database.DB.Where("post_id = ? AND user_id = ? AND accepted_at IS NULL", c.Params("id"), middleware.CurrentUser(c).ID).
+		First(&invitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Invitation not found",
+		})
+		return invitation, false
+	}
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching invitation",
+		})
+		return invitation, false
+	}
+	return invitation, true
+}
+
+// AcceptInvitation accepts a collaboration invitation on a post
+func AcceptInvitation(c *fiber.Ctx) error {
+	invitation, ok := findInvitation(c)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&invitation).Update("accepted_at", &now).Error; err != nil {
+		log.Error("--> CollaboratorController: AcceptInvitation: Failed to accept invitation: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to accept invitation",
+		})
+	}
+	invitation.AcceptedAt = &now
+
+	return c.JSON(fiber.Map{
+		"data":    invitation,
+		"message": "Invitation accepted successfully",
+	})
+}
+
+// DeclineInvitation declines a collaboration invitation on a post
+func DeclineInvitation(c *fiber.Ctx) error {
+	invitation, ok := findInvitation(c)
+	if !ok {
+		return nil
+	}
+
+	if err := database.DB.Delete(&invitation).Error; err != nil {
+		log.Error("--> CollaboratorController: DeclineInvitation: Failed to decline invitation: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to decline invitation",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invitation declined successfully",
+	})
+}
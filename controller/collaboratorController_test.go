@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bloomingFlower/blog-backend/models"
+)
+
+func TestPostAuthor(t *testing.T) {
+	username := "kim"
+	user := models.User{
+		ID:            3,
+		Username:      &username,
+		FirstName:     "Minji",
+		LastName:      "Kim",
+		Email:         "kim@example.com",
+		GithubID:      42,
+		PublicAddress: "0xabc",
+		Password:      []byte("hash"),
+		Phone:         "010-0000-0000",
+		Picture:       "https://example.com/kim.png",
+		Role:          models.RoleAdmin,
+		Language:      "ko",
+	}
+	data, err := json.Marshal(postAuthor(user))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	json.Unmarshal(data, &got)
+
+	want := map[string]interface{}{
+		"id":         float64(3),
+		"username":   "kim",
+		"first_name": "Minji",
+		"last_name":  "Kim",
+		"picture":    "https://example.com/kim.png",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %v, want %v", key, got[key], value)
+		}
+	}
+	for _, key := range []string{"email", "phone", "public_address", "role", "language"} {
+		if v, _ := got[key].(string); v != "" {
+			t.Errorf("%s = %q is exposed", key, v)
+		}
+	}
+	if v, _ := got["github_id"].(float64); v != 0 {
+		t.Errorf("github_id = %v is exposed", v)
+	}
+}
+
+func TestAuthorNames(t *testing.T) {
+	tests := []struct {
+		name string
+		post models.Post
+		want string
+	}{
+		{"owner only", models.Post{User: models.User{FirstName: "Minji", LastName: "Kim"}}, "Minji Kim"},
+		{"with collaborators", models.Post{
+			User:    models.User{FirstName: "ignored"},
+			Authors: []models.User{{FirstName: "Minji", LastName: "Kim"}, {FirstName: "Jun"}, {}},
+		}, "Minji Kim, Jun"},
+		{"no names", models.Post{}, ""},
+	}
+	for _, tt := range tests {
+		if got := authorNames(tt.post); got != tt.want {
+			t.Errorf("%s: authorNames = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCanManagePost(t *testing.T) {
+	post := models.Post{ID: 1, UserID: 3}
+	tests := []struct {
+		name string
+		user *models.User
+		want bool
+	}{
+		{"anonymous", nil, false},
+		{"owner", &models.User{ID: 3, Role: models.RoleAuthor}, true},
+		{"other author", &models.User{ID: 4, Role: models.RoleAuthor}, false},
+		{"editor", &models.User{ID: 4, Role: models.RoleEditor}, true},
+		{"blocked editor", &models.User{ID: 4, Role: models.RoleEditor, Blocked: true}, false},
+	}
+	for _, tt := range tests {
+		if got := canManagePost(tt.user, post); got != tt.want {
+			t.Errorf("%s: canManagePost = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,43 +1,177 @@
 package controller
 
 import (
+	"crypto/subtle"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
 	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/microcosm-cc/bluemonday"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// checkCommentPost checks that the post exists and the request may see it,
+// through a share link that allows comments for hidden posts. It writes the
+// error response and returns false otherwise.
+func checkCommentPost(c *fiber.Ctx, postID uint) bool {
+	if err := viewablePosts(c, postID, true).Select("id").Where("id = ?", postID).First(&models.Post{}).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Post not found",
+		})
+		return false
+	}
+	return true
+}
+
+// setDeleteToken gives an anonymous comment a secret token its commenter
+// can delete it with, and returns the token. Only its hash is stored.
+func setDeleteToken(comment *models.Comment) (string, error) {
+	comment.DeleteToken = ""
+	if comment.UserID != nil {
+		return "", nil
+	}
+	token, hash, err := util.NewSecretToken()
+	if err != nil {
+		return "", err
+	}
+	comment.DeleteToken = hash
+	return token, nil
+}
+
+// createComment saves a new comment and its mentions. No other associations
+// are saved, so nested users or replies never get in with it.
+func createComment(comment *models.Comment) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(comment).Error; err != nil {
+			return err
+		}
+		return replaceMentions(tx, comment)
+	})
+}
+
+// createdCommentResponse is the response to a new comment or reply. The
+// delete token of anonymous comments is only sent here.
+func createdCommentResponse(comment models.Comment, deleteToken, message string) fiber.Map {
+	response := fiber.Map{
+		"message": message,
+		"data":    comment,
+	}
+	if deleteToken != "" {
+		response["delete_token"] = deleteToken
+	}
+	return response
+}
+
+// commentRight returns how the current request may delete or edit the
+// comment, one of the models.CommentVia values, or "" when it may not
+func commentRight(c *fiber.Ctx, comment models.Comment) string {
+	user := middleware.CurrentUser(c)
+	if user != nil && comment.UserID != nil && *comment.UserID == user.ID {
+		return models.CommentViaAuthor
+	}
+	if user.Can(models.PermModerateComments) {
+		return models.CommentViaModerator
+	}
+	if user != nil {
+		var post models.Post
+		if database.DB.Select("id", "user_id").First(&post, comment.PostID).Error == nil && canManagePost(user, post) {
+			return models.CommentViaPostAuthor
+		}
+	}
+
+	// 익명 댓글은 작성 시 받은 삭제 토큰으로 확인, 수정에도 같은 토큰 사용
+	token := c.Get("X-Comment-Token")
+	if token == "" {
+		token = c.Query("token")
+	}
+	if comment.UserID == nil && comment.DeleteToken != "" && token != "" &&
+		subtle.ConstantTimeCompare([]byte(util.HashSecretToken(token)), []byte(comment.DeleteToken)) == 1 {
+		return models.CommentViaToken
+	}
+	return ""
+}
+
+// auditComment records an action on a comment with the current user and IP
+func auditComment(tx *gorm.DB, c *fiber.Ctx, comment models.Comment, action, via string) error {
+	audit := models.CommentAudit{
+		CommentID: comment.ID,
+		PostID:    comment.PostID,
+		Action:    action,
+		Via:       via,
+		IPHash:    util.HashIP(c.IP()),
+	}
+	if user := middleware.CurrentUser(c); user != nil {
+		audit.ActorID = &user.ID
+	}
+	return tx.Create(&audit).Error
+}
+
 // GetComments get comments
 func GetComments(c *fiber.Ctx) error {
 	postID := c.Params("postId")
-	var comments []models.Comment
-
-	// Preload User and Children's User, selecting specific fields
-	result := database.DB.Preload("User", func(db *gorm.DB) *gorm.DB {
-		return db.Select("ID", "FirstName", "Picture")
-	}).Preload("Votes").Preload("Children", func(db *gorm.DB) *gorm.DB {
-		return db.Preload("User", func(db *gorm.DB) *gorm.DB {
-			return db.Select("ID", "FirstName", "Picture")
-		}).Preload("Votes")
-	}).Where("post_id = ? AND parent_id IS NULL", postID).Find(&comments)
-
-	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
-		log.Error("--> CommentController: GetComments: Failed to get comments: ", result.Error)
+
+	// Deleted posts have no comments to show
+	id, _ := strconv.ParseUint(postID, 10, 32)
+	if !checkCommentPost(c, uint(id)) {
+		return nil
+	}
+	mode, limit, after, ok := commentPageParams(c)
+	if !ok {
+		return nil
+	}
+
+	// Pending comments are shown only to their commenter and to moderators.
+	// The top-level comments are paged in SQL, then only their threads are
+	// loaded and nested up to the requested depth. Threads whose comments
+	// are all deleted are left out, so a page can be shorter than the limit.
+	var threads, total int64
+	rootIDs, next, err := topLevelPage(c, uint(id), mode, after, limit)
+	var tree *commentTree
+	if err == nil {
+		tree, err = loadCommentTree(c, uint(id), rootIDs)
+	}
+	if err == nil {
+		err = topLevelComments(c, uint(id)).Count(&threads).Error
+	}
+	if err == nil {
+		err = visibleComments(database.DB.Model(&models.Comment{}), middleware.CurrentUser(c)).
+			Where("post_id = ?", id).Count(&total).Error
+	}
+	if err != nil {
+		log.Error("--> CommentController: GetComments: Failed to get comments: ", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get comments",
 		})
 	}
 
+	tree.sort(mode)
+	depth := requestedCommentDepth(c)
 	return c.JSON(fiber.Map{
-		"data": comments,
+		"data": tree.thread(0, 1, depth),
+		"meta": fiber.Map{
+			"total":       total,
+			"threads":     threads,
+			"sort":        mode,
+			"depth":       depth,
+			"next_cursor": next,
+			"reactions":   commentReactions(),
+		},
 	})
 }
 
+// commentInput is what the client sends for a new comment or reply
+type commentInput struct {
+	Content string `json:"content" form:"content"`
+}
+
 // CreateComment create comment
 func CreateComment(c *fiber.Ctx) error {
 	postID := c.Params("postId")
@@ -47,8 +181,9 @@ func CreateComment(c *fiber.Ctx) error {
 		})
 	}
 
-	var comment models.Comment
-	if err := c.BodyParser(&comment); err != nil {
+	// Only the content comes from the client; everything else is set here
+	var data commentInput
+	if err := c.BodyParser(&data); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Failed to parse request body",
 		})
@@ -56,7 +191,7 @@ func CreateComment(c *fiber.Ctx) error {
 
 	// Sanitize comment content
 	p := bluemonday.UGCPolicy()
-	comment.Content = p.Sanitize(comment.Content)
+	comment := models.Comment{Content: plainMentions(p.Sanitize(data.Content))}
 
 	// Check comment length after sanitization
 	if len(comment.Content) > 3000 {
@@ -73,6 +208,9 @@ func CreateComment(c *fiber.Ctx) error {
 		})
 	}
 	comment.PostID = uint(postIDUint)
+	if !checkCommentPost(c, comment.PostID) {
+		return nil
+	}
 
 	// Get IP address
 	ip := c.IP()
@@ -81,7 +219,7 @@ func CreateComment(c *fiber.Ctx) error {
 	if ip != "127.0.0.1" {
 		// Check the number of comments written within the last minute from the same IP
 		var count int64
-		if err := database.DB.Model(&models.Comment{}).Where("ip_address = ? AND created_at > ?", ip, time.Now().Add(-1*time.Minute)).Count(&count).Error; err != nil {
+		if err := database.DB.Model(&models.Comment{}).Where("ip_hash = ? AND created_at > ?", util.HashIP(ip), time.Now().Add(-1*time.Minute)).Count(&count).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to check comment count",
 			})
@@ -94,35 +232,36 @@ func CreateComment(c *fiber.Ctx) error {
 		}
 	}
 
-	// Set userID if provided
-	if comment.UserID != nil && *comment.UserID != 0 {
-		// Optionally, you can verify if the user exists in the database
-		var user models.User
-		if err := database.DB.First(&user, comment.UserID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "Invalid User ID",
-				})
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify user",
-			})
-		}
-	} else {
-		comment.UserID = nil // Ensure it's nil for anonymous comments
+	// Set userID from the session; anonymous comments have none
+	if user := middleware.CurrentUser(c); user != nil {
+		comment.UserID = &user.ID
 	}
 
-	comment.IPAddress = ip
-	if err := database.DB.Create(&comment).Error; err != nil {
+	comment.IPHash = util.HashIP(ip)
+	holdNewComment(c, &comment)
+	deleteToken, err := setDeleteToken(&comment)
+	if err != nil {
+		log.Error("--> CommentController: CreateComment: Failed to generate delete token: ", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create comment",
 		})
 	}
+	if err := resolveMentions(&comment); err != nil {
+		log.Error("--> CommentController: CreateComment: Failed to resolve mentions: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create comment",
+		})
+	}
+	if err := createComment(&comment); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create comment",
+		})
+	}
+	notifyComments(comment.ID)
+	renderMentions(&comment)
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "Comment created successfully",
-		"data":    comment,
-	})
+	return c.Status(fiber.StatusCreated).JSON(createdCommentResponse(comment, deleteToken,
+		createdCommentMessage(comment, "Comment created successfully")))
 }
 
 // CreateReply create reply to comment
@@ -135,17 +274,17 @@ func CreateReply(c *fiber.Ctx) error {
 		})
 	}
 
-	var reply models.Comment
-	if err := c.BodyParser(&reply); err != nil {
+	// Only the content comes from the client; everything else is set here
+	var data commentInput
+	if err := c.BodyParser(&data); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Failed to parse request body",
-			"data":  reply,
 		})
 	}
 
 	// Sanitize reply content
 	p := bluemonday.UGCPolicy()
-	reply.Content = p.Sanitize(reply.Content)
+	reply := models.Comment{Content: plainMentions(p.Sanitize(data.Content))}
 
 	// Check reply length after sanitization
 	if len(reply.Content) > 3000 {
@@ -161,7 +300,7 @@ func CreateReply(c *fiber.Ctx) error {
 	if ip != "127.0.0.1" {
 		// Check the number of comments written within the last minute from the same IP
 		var count int64
-		if err := database.DB.Model(&models.Comment{}).Where("ip_address = ? AND created_at > ?", ip, time.Now().Add(-1*time.Minute)).Count(&count).Error; err != nil {
+		if err := database.DB.Model(&models.Comment{}).Where("ip_hash = ? AND created_at > ?", util.HashIP(ip), time.Now().Add(-1*time.Minute)).Count(&count).Error; err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to check comment count",
 			})
@@ -176,7 +315,7 @@ func CreateReply(c *fiber.Ctx) error {
 
 	// Find parent comment to get post_id
 	var parentComment models.Comment
-	if err := database.DB.First(&parentComment, parentID).Error; err != nil {
+	if err := visibleComments(database.DB, middleware.CurrentUser(c)).First(&parentComment, parentID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "Parent comment not found",
@@ -187,171 +326,260 @@ func CreateReply(c *fiber.Ctx) error {
 		})
 	}
 
+	if !checkCommentPost(c, parentComment.PostID) {
+		return nil
+	}
+
 	parentIDUint := uint(parentID)
 	reply.ParentID = &parentIDUint
 	reply.PostID = parentComment.PostID // Set PostID from parent comment
-	reply.IPAddress = ip
-	// Set userID if provided
-	if reply.UserID != nil && *reply.UserID != 0 {
-		// Optionally, you can verify if the user exists in the database
-		var user models.User
-		if err := database.DB.First(&user, reply.UserID).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-					"error": "Invalid User ID",
-				})
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to verify user",
-			})
-		}
-	} else {
-		reply.UserID = nil // Ensure it's nil for anonymous comments
+	reply.IPHash = util.HashIP(ip)
+	// Set userID from the session; anonymous replies have none
+	if user := middleware.CurrentUser(c); user != nil {
+		reply.UserID = &user.ID
 	}
 
-	if err := database.DB.Create(&reply).Error; err != nil {
+	holdNewComment(c, &reply)
+	deleteToken, err := setDeleteToken(&reply)
+	if err != nil {
+		log.Error("--> CommentController: CreateReply: Failed to generate delete token: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create reply",
+		})
+	}
+	if err := resolveMentions(&reply); err != nil {
+		log.Error("--> CommentController: CreateReply: Failed to resolve mentions: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create reply",
+		})
+	}
+	if err := createComment(&reply); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create reply",
 		})
 	}
+	notifyComments(reply.ID)
+	renderMentions(&reply)
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "Reply created successfully",
-		"data":    reply,
-	})
+	return c.Status(fiber.StatusCreated).JSON(createdCommentResponse(reply, deleteToken,
+		createdCommentMessage(reply, "Reply created successfully")))
 }
 
-// VoteComment vote comment
-func VoteComment(c *fiber.Ctx) error {
-	commentIDStr := c.Params("commentId")
-	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
+// findPostComment loads the comment from the :commentId param and checks that
+// it belongs to the :postId post. It writes the error response and returns
+// false otherwise.
+func findPostComment(c *fiber.Ctx) (models.Comment, bool) {
+	var comment models.Comment
+	commentID, err := strconv.ParseUint(c.Params("commentId"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid comment ID",
 		})
+		return comment, false
 	}
 
-	var vote models.Vote
-	if err := c.BodyParser(&vote); err != nil {
+	if err := database.DB.First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Comment not found",
+			})
+			return comment, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to find comment",
+		})
+		return comment, false
+	}
+
+	if c.Params("postId") != strconv.FormatUint(uint64(comment.PostID), 10) {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Comment not found",
+		})
+		return comment, false
+	}
+	return comment, true
+}
+
+// commentEditWindow returns how long commenters may edit their comments,
+// from COMMENT_EDIT_MINUTES (default 15). Zero means no limit. Moderators and
+// post authors may always edit.
+func commentEditWindow() time.Duration {
+	minutes := 15
+	if v := os.Getenv("COMMENT_EDIT_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			minutes = n
+		}
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// EditComment changes the content of a comment and keeps the previous
+// content as a revision
+func EditComment(c *fiber.Ctx) error {
+	comment, ok := findPostComment(c)
+	if !ok {
+		return nil
+	}
+
+	via := commentRight(c, comment)
+	if via == "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You are not authorized to edit this comment",
+		})
+	}
+	if via == models.CommentViaAuthor || via == models.CommentViaToken {
+		// 미래 시각이 저장된 예전 댓글도 수정 기간이 지난 것으로 봄
+		age := time.Since(comment.CreatedAt)
+		if window := commentEditWindow(); window > 0 && (age < 0 || age > window) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "The comment can no longer be edited",
+			})
+		}
+	}
+
+	var data struct {
+		Content string `json:"content" form:"content"`
+	}
+	if err := c.BodyParser(&data); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Failed to parse request body",
 		})
 	}
 
-	// Sanitize emoji
+	// Sanitize comment content
 	p := bluemonday.UGCPolicy()
-	vote.Emoji = p.Sanitize(vote.Emoji)
-
-	// Check emoji length
-	if len(vote.Emoji) > 20 {
+	content := plainMentions(p.Sanitize(data.Content))
+	if strings.TrimSpace(content) == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Emoji exceeds 20 characters limit",
+			"error": "Comment content is required",
 		})
 	}
-
-	// Check if the comment exists
-	if err := database.DB.First(&models.Comment{}, commentID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Comment not found",
-			})
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to check comment",
+	if len(content) > 3000 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Comment content exceeds 3000 characters limit",
+		})
+	}
+	if content == comment.Content {
+		database.DB.Where("comment_id = ?", comment.ID).Find(&comment.Mentions)
+		renderMentions(&comment)
+		return c.JSON(fiber.Map{
+			"message": "Comment unchanged",
+			"data":    comment,
 		})
 	}
 
-	vote.CommentID = uint(commentID)
+	revision := models.CommentRevision{
+		CommentID: comment.ID,
+		Content:   comment.Content,
+		Via:       via,
+	}
+	if user := middleware.CurrentUser(c); user != nil {
+		revision.EditedBy = &user.ID
+	}
 
-	// Get IP address
-	ip := c.IP()
+	now := time.Now()
+	comment.Content = content
+	comment.Edited = true
+	comment.EditedAt = &now
+	if err := resolveMentions(&comment); err != nil {
+		log.Error("--> CommentController: EditComment: Failed to resolve mentions: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to edit comment",
+		})
+	}
+	updates := map[string]interface{}{
+		"content":   content,
+		"edited":    true,
+		"edited_at": now,
+	}
+	// 작성자가 고친 내용은 새 댓글처럼 다시 검사
+	if via == models.CommentViaAuthor || via == models.CommentViaToken {
+		checked := comment
+		holdNewComment(c, &checked)
+		if checked.Status != models.CommentApproved && comment.Status == models.CommentApproved {
+			comment.Status = checked.Status
+			comment.HoldReason = checked.HoldReason
+			comment.SpamScore = checked.SpamScore
+			updates["status"] = comment.Status
+			updates["hold_reason"] = comment.HoldReason
+			updates["spam_score"] = comment.SpamScore
+		}
+	}
 
-	// Determine if we're using UserID or IP
-	var query string
-	var args []interface{}
-	if vote.UserID != nil && *vote.UserID != 0 {
-		// User is logged in, use UserID
-		query = "comment_id = ? AND user_id = ?"
-		args = []interface{}{vote.CommentID, *vote.UserID}
-		vote.IPAddress = "" // Clear IP address for logged-in users
-	} else {
-		// Anonymous user, use IP
-		query = "comment_id = ? AND ip_address = ?"
-		args = []interface{}{vote.CommentID, ip}
-		vote.UserID = nil // Clear UserID for anonymous users
-		vote.IPAddress = ip
-	}
-
-	// Check if the vote already exists
-	var existingVote models.Vote
-	err = database.DB.Where(query, args...).First(&existingVote).Error
-	if err == nil {
-		// Update the existing vote
-		existingVote.Emoji = vote.Emoji
-		if err := database.DB.Save(&existingVote).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to update vote",
-			})
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
 		}
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"message": "Vote updated successfully",
-			"data":    existingVote,
-		})
-	} else if err == gorm.ErrRecordNotFound {
-		// Create a new vote
-		if err := database.DB.Create(&vote).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create vote",
-			})
+		if err := tx.Model(&comment).Omit(clause.Associations).UpdateColumns(updates).Error; err != nil {
+			return err
 		}
-		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-			"message": "Vote created successfully",
-			"data":    vote,
-		})
-	} else {
+		if err := replaceMentions(tx, &comment); err != nil {
+			return err
+		}
+		return auditComment(tx, c, comment, models.CommentAuditEdit, via)
+	})
+	if err != nil {
+		log.Error("--> CommentController: EditComment: Failed to edit comment: ", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to process vote",
+			"error": "Failed to edit comment",
 		})
 	}
+	notifyMentions(comment.ID)
+	renderMentions(&comment)
+
+	return c.JSON(fiber.Map{
+		"message": createdCommentMessage(comment, "Comment edited successfully"),
+		"data":    comment,
+	})
 }
 
-// DeleteComment deletes a comment
-func DeleteComment(c *fiber.Ctx) error {
-	// Get comment ID from URL parameter
-	commentIDStr := c.Params("commentId")
-	commentID, err := strconv.ParseUint(commentIDStr, 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid comment ID",
-		})
+// GetCommentHistory lists the previous versions of a comment, newest first
+func GetCommentHistory(c *fiber.Ctx) error {
+	comment, ok := findPostComment(c)
+	if !ok {
+		return nil
 	}
 
-	// Find the comment
-	var comment models.Comment
-	if err := database.DB.First(&comment, commentID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Comment not found",
-			})
-		}
+	var revisions []models.CommentRevision
+	if err := database.DB.Where("comment_id = ?", comment.ID).Order("created_at DESC, id DESC").Find(&revisions).Error; err != nil {
+		log.Error("--> CommentController: GetCommentHistory: Failed to get revisions: ", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to find comment",
+			"error": "Failed to get comment history",
 		})
 	}
 
-	// Check if the user is authorized to delete the comment
-	// This is a placeholder. You should implement proper authorization logic.
-	// For example, check if the user is an admin or the comment owner.
-	isAuthorized := true // Replace with actual authorization logic
+	return c.JSON(fiber.Map{
+		"data": revisions,
+		"meta": fiber.Map{
+			"current": comment.Content,
+		},
+	})
+}
+
+// DeleteComment deletes a comment
+func DeleteComment(c *fiber.Ctx) error {
+	comment, ok := findPostComment(c)
+	if !ok {
+		return nil
+	}
 
-	if !isAuthorized {
+	// Check if the user is authorized to delete the comment
+	via := commentRight(c, comment)
+	if via == "" {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "You are not authorized to delete this comment",
 		})
 	}
 
-	// Soft delete the comment
-	if err := database.DB.Model(&comment).Update("deleted_at", time.Now()).Error; err != nil {
+	// Soft delete the comment and record who deleted it
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&comment).Update("deleted_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return auditComment(tx, c, comment, models.CommentAuditDelete, via)
+	})
+	if err != nil {
+		log.Error("--> CommentController: DeleteComment: Failed to delete comment: ", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete comment",
 		})
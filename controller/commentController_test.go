@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSetDeleteToken(t *testing.T) {
+	anonymous := models.Comment{DeleteToken: "old"}
+	token, err := setDeleteToken(&anonymous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token == "" || anonymous.DeleteToken != util.HashSecretToken(token) {
+		t.Errorf("anonymous comment: token %q, stored %q", token, anonymous.DeleteToken)
+	}
+
+	userID := uint(3)
+	signedIn := models.Comment{UserID: &userID, DeleteToken: "old"}
+	if token, err := setDeleteToken(&signedIn); err != nil || token != "" || signedIn.DeleteToken != "" {
+		t.Errorf("signed in comment: token %q, stored %q, err %v", token, signedIn.DeleteToken, err)
+	}
+}
+
+func TestCreatedCommentResponse(t *testing.T) {
+	comment := models.Comment{Content: "hi"}
+	if got := createdCommentResponse(comment, "", "ok"); got["delete_token"] != nil {
+		t.Errorf("delete_token = %v, want none", got["delete_token"])
+	}
+	if got := createdCommentResponse(comment, "abc", "ok"); got["delete_token"] != "abc" || got["message"] != "ok" {
+		t.Errorf("response = %v", got)
+	}
+}
+
+func TestCommentRight(t *testing.T) {
+	useDryRunDB(t) // 포스트 조회는 항상 찾지 못함
+	authorID := uint(3)
+	token := "secret"
+	owned := models.Comment{PostID: 1, UserID: &authorID}
+	anonymous := models.Comment{PostID: 1, DeleteToken: util.HashSecretToken(token)}
+
+	tests := []struct {
+		name    string
+		comment models.Comment
+		user    *models.User
+		token   string
+		want    string
+	}{
+		{"author", owned, &models.User{ID: 3, Role: models.RoleCommenter}, "", models.CommentViaAuthor},
+		{"other user", owned, &models.User{ID: 4, Role: models.RoleCommenter}, "", ""},
+		{"moderator", owned, &models.User{ID: 1, Role: models.RoleAdmin}, "", models.CommentViaModerator},
+		{"token", anonymous, nil, token, models.CommentViaToken},
+		{"wrong token", anonymous, nil, "guess", ""},
+		{"no token", anonymous, nil, "", ""},
+		// 로그인한 사용자의 댓글은 토큰으로 지울 수 없다
+		{"token for signed in comment", models.Comment{PostID: 1, UserID: &authorID, DeleteToken: util.HashSecretToken(token)}, nil, token, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			var got string
+			app.Get("/", func(c *fiber.Ctx) error {
+				c.Locals("user", tt.user)
+				got = commentRight(c, tt.comment)
+				return nil
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.token != "" {
+				req.Header.Set("X-Comment-Token", tt.token)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("commentRight = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentEditWindow(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 15 * time.Minute},
+		{"60", time.Hour},
+		{"0", 0},
+		{"-5", 15 * time.Minute},
+		{"soon", 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Setenv("COMMENT_EDIT_MINUTES", tt.env)
+		if got := commentEditWindow(); got != tt.want {
+			t.Errorf("commentEditWindow with COMMENT_EDIT_MINUTES=%q = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
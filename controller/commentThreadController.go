@@ -0,0 +1,538 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// deletedCommentContent replaces the content of deleted comments that are
+// kept in a thread because they have replies
+const deletedCommentContent = "[deleted]"
+
+const (
+	defaultCommentPageSize = 20
+	maxCommentPageSize     = 100
+)
+
+// Comment sort modes. The mode applies to every level of a thread.
+const (
+	commentSortOldest        = "oldest"
+	commentSortNewest        = "newest"
+	commentSortTop           = "top"           // most reactions
+	commentSortControversial = "controversial" // many reactions, split between different emoji
+)
+
+// commentCursor is the position after which the next page starts
+type commentCursor struct {
+	Score float64 `json:"s,omitempty"`
+	Time  int64   `json:"t"`
+	ID    uint    `json:"id"`
+}
+
+func (cc commentCursor) encode() string {
+	b, _ := json.Marshal(cc)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCommentCursor(s string) (commentCursor, bool) {
+	var cc commentCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || json.Unmarshal(b, &cc) != nil {
+		return cc, false
+	}
+	return cc, true
+}
+
+// commentMaxDepth returns how many levels of comments a response nests at
+// most, from COMMENT_MAX_DEPTH (default 6). Deeper replies are loaded with
+// the thread endpoint.
+func commentMaxDepth() int {
+	depth := 6
+	if v := os.Getenv("COMMENT_MAX_DEPTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			depth = n
+		}
+	}
+	return depth
+}
+
+// requestedCommentDepth returns the depth query param, capped at commentMaxDepth
+func requestedCommentDepth(c *fiber.Ctx) int {
+	maxDepth := commentMaxDepth()
+	depth := c.QueryInt("depth", maxDepth)
+	if depth < 1 || depth > maxDepth {
+		depth = maxDepth
+	}
+	return depth
+}
+
+// commentTree holds the comments under some roots and where they sit in the tree
+type commentTree struct {
+	comments  []models.Comment
+	index     map[uint]int   // comment ID -> position in comments
+	children  map[uint][]int // parent ID, 0 for the roots -> replies
+	live      map[uint]int   // comment ID -> comments in its subtree that are not deleted
+	reactions reactionSummary
+	sortMode  string
+}
+
+// loadCommentTree loads the given comments of a post with all their replies
+// that the request may see, deleted ones included, and sums up their
+// reactions. The roots keep the given order.
+func loadCommentTree(c *fiber.Ctx, postID uint, rootIDs []uint) (*commentTree, error) {
+	user := middleware.CurrentUser(c)
+	load := func(cond string, ids []uint) ([]models.Comment, error) {
+		var comments []models.Comment
+		err := visibleComments(database.DB.Unscoped(), user).
+			Preload("User", func(db *gorm.DB) *gorm.DB {
+				return db.Select("ID", "Username", "FirstName", "Picture")
+			}).
+			Preload("Mentions").
+			Where("post_id = ?", postID).
+			Where(cond, ids).
+			Order("created_at, id").
+			Find(&comments).Error
+		return comments, err
+	}
+
+	tree := &commentTree{
+		index:    make(map[uint]int),
+		children: make(map[uint][]int),
+		live:     make(map[uint]int),
+	}
+	if len(rootIDs) == 0 {
+		return tree, nil
+	}
+
+	roots, err := load("id IN ?", rootIDs)
+	if err != nil {
+		return nil, err
+	}
+	position := make(map[uint]int, len(rootIDs))
+	for i, id := range rootIDs {
+		position[id] = i
+	}
+	sort.Slice(roots, func(a, b int) bool { return position[roots[a].ID] < position[roots[b].ID] })
+	for _, root := range roots {
+		tree.index[root.ID] = len(tree.comments)
+		tree.children[0] = append(tree.children[0], len(tree.comments))
+		tree.comments = append(tree.comments, root)
+	}
+
+	// 한 단계씩 답글을 불러옴. 보이지 않는 댓글의 답글은 불러오지 않음
+	parents := make([]uint, 0, len(roots))
+	for _, root := range roots {
+		parents = append(parents, root.ID)
+	}
+	for len(parents) > 0 {
+		replies, err := load("parent_id IN ?", parents)
+		if err != nil {
+			return nil, err
+		}
+		var next []uint
+		for _, reply := range replies {
+			tree.index[reply.ID] = len(tree.comments)
+			tree.children[*reply.ParentID] = append(tree.children[*reply.ParentID], len(tree.comments))
+			tree.comments = append(tree.comments, reply)
+			next = append(next, reply.ID)
+		}
+		parents = next
+	}
+	tree.countLive(0)
+
+	ids := make([]uint, 0, len(tree.comments))
+	for _, comment := range tree.comments {
+		ids = append(ids, comment.ID)
+	}
+	tree.reactions, err = loadReactions(c, ids)
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// reactionCount returns how many reactions the comment has
+func (t *commentTree) reactionCount(i int) int {
+	n := 0
+	for _, reaction := range t.reactions.counts[t.comments[i].ID] {
+		n += reaction.Count
+	}
+	return n
+}
+
+// controversy is high for comments with many reactions that are evenly split
+// between the two most used emoji: reactions ^ (second / first)
+func (t *commentTree) controversy(i int) float64 {
+	// counts are sorted from the most used emoji
+	counts := t.reactions.counts[t.comments[i].ID]
+	if len(counts) < 2 {
+		return 0
+	}
+	first, second := counts[0].Count, counts[1].Count
+	return math.Pow(float64(first+second), float64(second)/float64(first))
+}
+
+// cursor returns the sort key of the comment at position i
+func (t *commentTree) cursor(i int) commentCursor {
+	comment := t.comments[i]
+	cc := commentCursor{Time: comment.CreatedAt.UnixNano(), ID: comment.ID}
+	switch t.sortMode {
+	case commentSortTop:
+		cc.Score = float64(t.reactionCount(i))
+	case commentSortControversial:
+		cc.Score = t.controversy(i)
+	}
+	return cc
+}
+
+// before reports whether a sorts before b in the sort mode. Ties are broken
+// by age, then by ID, so every comment has a distinct position.
+func (t *commentTree) before(a, b commentCursor) bool {
+	if t.sortMode == commentSortNewest {
+		if a.Time != b.Time {
+			return a.Time > b.Time
+		}
+		return a.ID > b.ID
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Time != b.Time {
+		return a.Time < b.Time
+	}
+	return a.ID < b.ID
+}
+
+// sort orders the replies of every comment by the sort mode. The roots keep
+// the order they were loaded in.
+func (t *commentTree) sort(mode string) {
+	t.sortMode = mode
+	for parent, replies := range t.children {
+		if parent == 0 {
+			continue
+		}
+		sort.SliceStable(replies, func(a, b int) bool {
+			return t.before(t.cursor(replies[a]), t.cursor(replies[b]))
+		})
+	}
+}
+
+// page returns up to limit replies of parent that come after the cursor, and
+// the cursor of the next page or "" on the last page
+func (t *commentTree) page(parent uint, after *commentCursor, limit, depth, maxDepth int) ([]models.Comment, string) {
+	items := []models.Comment{}
+	next := ""
+	for _, i := range t.children[parent] {
+		if t.live[t.comments[i].ID] == 0 {
+			continue
+		}
+		if after != nil && !t.before(*after, t.cursor(i)) {
+			continue
+		}
+		if len(items) == limit {
+			next = t.cursor(t.index[items[len(items)-1].ID]).encode()
+			break
+		}
+		items = append(items, t.node(i, depth, maxDepth))
+	}
+	return items, next
+}
+
+// threadCount returns how many replies of parent are shown
+func (t *commentTree) threadCount(parent uint) int {
+	n := 0
+	for _, i := range t.children[parent] {
+		if t.live[t.comments[i].ID] > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// countLive fills live for the subtree under parent and returns its total
+func (t *commentTree) countLive(parent uint) int {
+	total := 0
+	for _, i := range t.children[parent] {
+		comment := t.comments[i]
+		n := t.countLive(comment.ID)
+		if !comment.DeletedAt.Valid {
+			n++
+		}
+		t.live[comment.ID] = n
+		total += n
+	}
+	return total
+}
+
+// node returns the comment at position i with its replies nested up to
+// maxDepth levels, counting the comment as level depth. Deleted comments
+// become tombstones.
+func (t *commentTree) node(i, depth, maxDepth int) models.Comment {
+	comment := t.comments[i]
+	if comment.DeletedAt.Valid {
+		comment = tombstone(comment)
+		reactionSummary{}.apply(&comment)
+	} else {
+		t.reactions.apply(&comment)
+		renderMentions(&comment)
+	}
+	if depth >= maxDepth {
+		// 이어지는 답글은 스레드 엔드포인트로 조회
+		comment.Children = nil
+		comment.MoreReplies = t.replyCount(comment.ID)
+		return comment
+	}
+	comment.Children = t.thread(comment.ID, depth+1, maxDepth)
+	return comment
+}
+
+// thread returns the replies of parent that still have something to show
+func (t *commentTree) thread(parent uint, depth, maxDepth int) []models.Comment {
+	thread := []models.Comment{}
+	for _, i := range t.children[parent] {
+		if t.live[t.comments[i].ID] == 0 {
+			continue
+		}
+		thread = append(thread, t.node(i, depth, maxDepth))
+	}
+	return thread
+}
+
+// replyCount returns how many replies below the comment are not deleted
+func (t *commentTree) replyCount(id uint) int {
+	n := t.live[id]
+	if i, ok := t.index[id]; ok && !t.comments[i].DeletedAt.Valid {
+		n--
+	}
+	return n
+}
+
+// tombstone hides everything but the position of a deleted comment
+func tombstone(comment models.Comment) models.Comment {
+	return models.Comment{
+		Model:     gorm.Model{ID: comment.ID, CreatedAt: comment.CreatedAt},
+		PostID:    comment.PostID,
+		ParentID:  comment.ParentID,
+		Content:   deletedCommentContent,
+		Status:    comment.Status,
+		CreatedAt: comment.CreatedAt,
+		Deleted:   true,
+	}
+}
+
+// commentPageParams reads the sort, limit and cursor params of a comment page.
+// It answers the request itself when they are invalid.
+func commentPageParams(c *fiber.Ctx) (string, int, *commentCursor, bool) {
+	mode := c.Query("sort", commentSortOldest)
+	switch mode {
+	case commentSortOldest, commentSortNewest, commentSortTop, commentSortControversial:
+	default:
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "sort must be oldest, newest, top or controversial",
+		})
+		return "", 0, nil, false
+	}
+	limit := c.QueryInt("limit", defaultCommentPageSize)
+	if limit < 1 || limit > maxCommentPageSize {
+		limit = defaultCommentPageSize
+	}
+	var after *commentCursor
+	if s := c.Query("cursor"); s != "" {
+		cursor, ok := decodeCommentCursor(s)
+		if !ok {
+			c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid cursor",
+			})
+			return "", 0, nil, false
+		}
+		after = &cursor
+	}
+	return mode, limit, after, true
+}
+
+// Votes that are not removed, summed per comment like reactionSummary
+const (
+	reactionCountSQL = "(SELECT COUNT(*) FROM votes WHERE votes.comment_id = comments.id AND votes.deleted_at IS NULL)"
+	emojiCountSQL    = "(SELECT COUNT(*) AS n FROM votes WHERE votes.comment_id = comments.id AND votes.deleted_at IS NULL " +
+		"GROUP BY votes.emoji ORDER BY n DESC LIMIT 1 OFFSET %d)"
+)
+
+// commentScoreSQL returns the SQL expression of the score the top-level
+// comments are sorted by, the same as commentTree.cursor computes
+func commentScoreSQL(mode string) string {
+	switch mode {
+	case commentSortTop:
+		return reactionCountSQL
+	case commentSortControversial:
+		first, second := fmt.Sprintf(emojiCountSQL, 0), fmt.Sprintf(emojiCountSQL, 1)
+		return fmt.Sprintf("COALESCE(POW(%s + %s, (%s + 0E0) / %s), 0)", first, second, second, first)
+	}
+	return "0"
+}
+
+// topLevelComments selects the top-level comments of a post that the request
+// may see. Deleted ones are only kept as tombstones when they have replies.
+func topLevelComments(c *fiber.Ctx, postID uint) *gorm.DB {
+	return visibleComments(database.DB.Unscoped().Model(&models.Comment{}), middleware.CurrentUser(c)).
+		Where("comments.post_id = ? AND comments.parent_id IS NULL", postID).
+		Where("(comments.deleted_at IS NULL OR EXISTS (SELECT 1 FROM comments AS replies WHERE replies.parent_id = comments.id))")
+}
+
+// topLevelPage returns the IDs of up to limit top-level comments that come
+// after the cursor in the sort mode, and the cursor of the next page or "" on
+// the last page
+func topLevelPage(c *fiber.Ctx, postID uint, mode string, after *commentCursor, limit int) ([]uint, string, error) {
+	score := commentScoreSQL(mode)
+	query := topLevelComments(c, postID).Select("comments.id, comments.created_at, " + score + " AS score")
+	if mode == commentSortNewest {
+		if after != nil {
+			t := time.Unix(0, after.Time)
+			query = query.Where("(comments.created_at < ? OR (comments.created_at = ? AND comments.id < ?))", t, t, after.ID)
+		}
+		query = query.Order("comments.created_at DESC, comments.id DESC")
+	} else {
+		if after != nil {
+			t := time.Unix(0, after.Time)
+			query = query.Where("("+score+" < ? OR ("+score+" = ? AND (comments.created_at > ? OR (comments.created_at = ? AND comments.id > ?))))",
+				after.Score, after.Score, t, t, after.ID)
+		}
+		query = query.Order("score DESC, comments.created_at, comments.id")
+	}
+
+	var rows []struct {
+		ID        uint
+		CreatedAt time.Time
+		Score     float64
+	}
+	if err := query.Limit(limit + 1).Scan(&rows).Error; err != nil {
+		return nil, "", err
+	}
+	next := ""
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		next = commentCursor{Score: last.Score, Time: last.CreatedAt.UnixNano(), ID: last.ID}.encode()
+	}
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids, next, nil
+}
+
+// visibleCommentPath reports whether the comment belongs to the post and the
+// request may see it and every comment above it, since the replies of hidden
+// comments are hidden too
+func visibleCommentPath(c *fiber.Ctx, postID, commentID uint) (bool, error) {
+	user := middleware.CurrentUser(c)
+	for id := &commentID; id != nil; {
+		var comment models.Comment
+		err := visibleComments(database.DB.Unscoped().Select("id", "parent_id"), user).
+			Where("id = ? AND post_id = ?", *id, postID).
+			Take(&comment).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		id = comment.ParentID
+	}
+	return true, nil
+}
+
+// loadCommentSubtree loads the comment with its replies for GetCommentReplies
+// and GetCommentThread. It answers the request itself when the comment cannot
+// be shown.
+func loadCommentSubtree(c *fiber.Ctx, handler string) (*commentTree, uint, bool) {
+	postID, _ := strconv.ParseUint(c.Params("postId"), 10, 32)
+	if !checkCommentPost(c, uint(postID)) {
+		return nil, 0, false
+	}
+	commentID, err := strconv.ParseUint(c.Params("commentId"), 10, 32)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid comment ID",
+		})
+		return nil, 0, false
+	}
+
+	visible, err := visibleCommentPath(c, uint(postID), uint(commentID))
+	var tree *commentTree
+	if err == nil && visible {
+		tree, err = loadCommentTree(c, uint(postID), []uint{uint(commentID)})
+	}
+	if err != nil {
+		log.Error("--> CommentThreadController: "+handler+": Failed to get comments: ", err)
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get comments",
+		})
+		return nil, 0, false
+	}
+	if !visible || tree.live[uint(commentID)] == 0 {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Comment not found",
+		})
+		return nil, 0, false
+	}
+	return tree, uint(commentID), true
+}
+
+// GetCommentReplies returns a page of the replies of a comment, for loading
+// replies lazily with depth=1
+func GetCommentReplies(c *fiber.Ctx) error {
+	mode, limit, after, ok := commentPageParams(c)
+	if !ok {
+		return nil
+	}
+	tree, commentID, ok := loadCommentSubtree(c, "GetCommentReplies")
+	if !ok {
+		return nil
+	}
+
+	tree.sort(mode)
+	depth := requestedCommentDepth(c)
+	items, next := tree.page(commentID, after, limit, 1, depth)
+	return c.JSON(fiber.Map{
+		"data": items,
+		"meta": fiber.Map{
+			"total":       tree.replyCount(commentID),
+			"threads":     tree.threadCount(commentID),
+			"sort":        mode,
+			"depth":       depth,
+			"next_cursor": next,
+			"reactions":   commentReactions(),
+		},
+	})
+}
+
+// GetCommentThread returns a comment with its replies, to continue a thread
+// that GetComments cut off at the maximum depth
+func GetCommentThread(c *fiber.Ctx) error {
+	tree, commentID, ok := loadCommentSubtree(c, "GetCommentThread")
+	if !ok {
+		return nil
+	}
+
+	depth := requestedCommentDepth(c)
+	return c.JSON(fiber.Map{
+		"data": tree.node(tree.index[commentID], 1, depth),
+		"meta": fiber.Map{
+			"depth": depth,
+		},
+	})
+}
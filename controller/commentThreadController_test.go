@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// testComment is a comment created minutes after a fixed time
+func testComment(id uint, parent uint, minutes int, deleted bool) models.Comment {
+	created := time.Date(2024, 5, 1, 12, minutes, 0, 0, time.UTC)
+	comment := models.Comment{
+		Model:     gorm.Model{ID: id, CreatedAt: created},
+		PostID:    1,
+		Content:   "comment",
+		Status:    models.CommentApproved,
+		CreatedAt: created,
+	}
+	if parent != 0 {
+		comment.ParentID = &parent
+	}
+	if deleted {
+		comment.DeletedAt = gorm.DeletedAt{Time: created, Valid: true}
+	}
+	return comment
+}
+
+// newTestTree builds a commentTree the way loadCommentTree does, with the
+// top-level comments as roots in the given order
+func newTestTree(comments []models.Comment, counts map[uint][]models.ReactionCount) *commentTree {
+	tree := &commentTree{
+		index:     make(map[uint]int),
+		children:  make(map[uint][]int),
+		live:      make(map[uint]int),
+		reactions: reactionSummary{counts: counts},
+	}
+	for _, comment := range comments {
+		parent := uint(0)
+		if comment.ParentID != nil {
+			parent = *comment.ParentID
+		}
+		tree.index[comment.ID] = len(tree.comments)
+		tree.children[parent] = append(tree.children[parent], len(tree.comments))
+		tree.comments = append(tree.comments, comment)
+	}
+	tree.countLive(0)
+	return tree
+}
+
+// threadTree is
+//
+//	1
+//	├─ 2 (deleted)
+//	│  └─ 4
+//	└─ 3 (deleted)
+//	5 (deleted)
+//	6
+//	└─ 7
+//	   └─ 8
+func threadTree() *commentTree {
+	return newTestTree([]models.Comment{
+		testComment(1, 0, 0, false),
+		testComment(5, 0, 1, true),
+		testComment(6, 0, 2, false),
+		testComment(2, 1, 3, true),
+		testComment(3, 1, 4, true),
+		testComment(7, 6, 5, false),
+		testComment(4, 2, 6, false),
+		testComment(8, 7, 7, false),
+	}, nil)
+}
+
+// threadShape writes the IDs of comments with their nested replies in
+// parentheses, such as "1(2(4)) 6"
+func threadShape(comments []models.Comment) string {
+	parts := make([]string, len(comments))
+	for i, comment := range comments {
+		parts[i] = strconv.FormatUint(uint64(comment.ID), 10)
+		if len(comment.Children) > 0 {
+			parts[i] += "(" + threadShape(comment.Children) + ")"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestCommentTreeLive(t *testing.T) {
+	tree := threadTree()
+	tests := []struct {
+		id                     uint
+		live, replies, threads int
+	}{
+		{0, 0, 0, 2},
+		{1, 2, 1, 1},
+		{2, 1, 1, 1},
+		{3, 0, 0, 0},
+		{5, 0, 0, 0},
+		{6, 3, 2, 1},
+		{8, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		if tt.id != 0 && tree.live[tt.id] != tt.live {
+			t.Errorf("live[%d] = %d, want %d", tt.id, tree.live[tt.id], tt.live)
+		}
+		if tt.id != 0 {
+			if got := tree.replyCount(tt.id); got != tt.replies {
+				t.Errorf("replyCount(%d) = %d, want %d", tt.id, got, tt.replies)
+			}
+		}
+		if got := tree.threadCount(tt.id); got != tt.threads {
+			t.Errorf("threadCount(%d) = %d, want %d", tt.id, got, tt.threads)
+		}
+	}
+}
+
+func TestCommentTreeThread(t *testing.T) {
+	tree := threadTree()
+	thread := tree.thread(0, 1, 6)
+	// 답글이 남지 않은 삭제된 댓글 3과 5는 빠진다
+	if got, want := threadShape(thread), "1(2(4)) 6(7(8))"; got != want {
+		t.Fatalf("thread = %q, want %q", got, want)
+	}
+
+	deleted := thread[0].Children[0]
+	if !deleted.Deleted || deleted.Content != deletedCommentContent {
+		t.Errorf("comment 2 = %+v, want a tombstone", deleted)
+	}
+	if deleted.Reactions == nil || deleted.MyReactions == nil {
+		t.Error("tombstone reactions are nil, want empty lists")
+	}
+	if live := thread[0]; live.Deleted || live.Content != "comment" || live.Reactions == nil {
+		t.Errorf("comment 1 = %+v", live)
+	}
+}
+
+func TestCommentTreeMaxDepth(t *testing.T) {
+	tree := threadTree()
+	thread := tree.thread(0, 1, 2)
+	if got, want := threadShape(thread), "1(2) 6(7)"; got != want {
+		t.Fatalf("thread = %q, want %q", got, want)
+	}
+	for _, reply := range []models.Comment{thread[0].Children[0], thread[1].Children[0]} {
+		if reply.Children != nil || reply.MoreReplies != 1 {
+			t.Errorf("comment %d: Children %v, MoreReplies %d, want nil and 1", reply.ID, reply.Children, reply.MoreReplies)
+		}
+	}
+	if thread[0].MoreReplies != 0 {
+		t.Errorf("comment 1: MoreReplies = %d, want 0 above the max depth", thread[0].MoreReplies)
+	}
+}
+
+func TestTombstone(t *testing.T) {
+	userID := uint(3)
+	comment := testComment(2, 1, 0, true)
+	comment.UserID = &userID
+	comment.Content = "secret"
+	comment.IPHash = "hash"
+	comment.User = models.User{ID: 3, FirstName: "Kim"}
+	comment.Mentions = []models.CommentMention{{Username: "lee"}}
+
+	got := tombstone(comment)
+	if got.ID != 2 || got.PostID != 1 || got.ParentID == nil || *got.ParentID != 1 || !got.CreatedAt.Equal(comment.CreatedAt) {
+		t.Errorf("tombstone lost its position: %+v", got)
+	}
+	if got.UserID != nil || got.User.ID != 0 || got.IPHash != "" || got.Mentions != nil {
+		t.Errorf("tombstone kept the commenter: %+v", got)
+	}
+	if got.Content != deletedCommentContent || !got.Deleted || got.DeletedAt.Valid {
+		t.Errorf("tombstone = %+v", got)
+	}
+}
+
+func TestRequestedCommentDepth(t *testing.T) {
+	tests := []struct {
+		env   string
+		query string
+		want  int
+	}{
+		{"", "", 6},
+		{"", "?depth=2", 2},
+		{"", "?depth=0", 6},
+		{"", "?depth=10", 6},
+		{"3", "", 3},
+		{"3", "?depth=4", 3},
+		{"0", "", 6},
+		{"deep", "?depth=1", 1},
+	}
+	for _, tt := range tests {
+		t.Setenv("COMMENT_MAX_DEPTH", tt.env)
+		app := fiber.New()
+		var got int
+		app.Get("/", func(c *fiber.Ctx) error {
+			got = requestedCommentDepth(c)
+			return nil
+		})
+		if _, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("COMMENT_MAX_DEPTH=%q %s: depth = %d, want %d", tt.env, tt.query, got, tt.want)
+		}
+	}
+}
+
+// sortTree has one root and four replies. By reactions 4 is the top
+// reply and 3, split between two emoji, the most controversial.
+func sortTree() *commentTree {
+	return newTestTree([]models.Comment{
+		testComment(9, 0, 9, false),
+		testComment(1, 0, 0, false),
+		testComment(2, 1, 1, false),
+		testComment(3, 1, 2, false),
+		testComment(4, 1, 3, false),
+		testComment(5, 1, 4, false),
+	}, map[uint][]models.ReactionCount{
+		2: {{Emoji: "👍", Count: 3}},
+		3: {{Emoji: "👍", Count: 2}, {Emoji: "❤️", Count: 2}},
+		4: {{Emoji: "👍", Count: 5}},
+	})
+}
+
+func TestCommentTreeSort(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{commentSortOldest, "9 1(2 3 4 5)"},
+		{commentSortNewest, "9 1(5 4 3 2)"},
+		{commentSortTop, "9 1(4 3 2 5)"},
+		// 반응이 하나뿐인 댓글은 오래된 순
+		{commentSortControversial, "9 1(3 2 4 5)"},
+	}
+	for _, tt := range tests {
+		tree := sortTree()
+		tree.sort(tt.mode)
+		// 최상위 댓글은 불러온 순서를 유지한다
+		if got := threadShape(tree.thread(0, 1, 6)); got != tt.want {
+			t.Errorf("sort %s: thread = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestCommentTreePage(t *testing.T) {
+	for _, mode := range []string{commentSortOldest, commentSortNewest, commentSortTop, commentSortControversial} {
+		tree := sortTree()
+		tree.sort(mode)
+		want := threadShape(tree.thread(1, 2, 6))
+
+		// 두 개씩 나눠 받아도 전체 순서와 같아야 한다
+		var pages []string
+		var after *commentCursor
+		for i := 0; i < 3; i++ {
+			items, next := tree.page(1, after, 2, 2, 6)
+			pages = append(pages, threadShape(items))
+			if next == "" {
+				break
+			}
+			cursor, ok := decodeCommentCursor(next)
+			if !ok {
+				t.Fatalf("sort %s: invalid next cursor %q", mode, next)
+			}
+			after = &cursor
+		}
+		if len(pages) != 2 {
+			t.Errorf("sort %s: %d pages, want 2", mode, len(pages))
+		}
+		if got := strings.Join(pages, " "); got != want {
+			t.Errorf("sort %s: pages = %q, want %q", mode, got, want)
+		}
+	}
+}
+
+func TestCommentCursor(t *testing.T) {
+	cc := commentCursor{Score: 4, Time: 1714564800000000000, ID: 3}
+	got, ok := decodeCommentCursor(cc.encode())
+	if !ok || got != cc {
+		t.Errorf("decodeCommentCursor(encode(%+v)) = %+v, %t", cc, got, ok)
+	}
+	for _, s := range []string{"!!!", "bm90IGpzb24"} {
+		if _, ok := decodeCommentCursor(s); ok {
+			t.Errorf("decodeCommentCursor(%q) is valid", s)
+		}
+	}
+}
+
+func TestCommentScoreSQL(t *testing.T) {
+	if got := commentScoreSQL(commentSortOldest); got != "0" {
+		t.Errorf("oldest score = %q, want 0", got)
+	}
+	if got := commentScoreSQL(commentSortNewest); got != "0" {
+		t.Errorf("newest score = %q, want 0", got)
+	}
+	if got := commentScoreSQL(commentSortTop); got != reactionCountSQL {
+		t.Errorf("top score = %q", got)
+	}
+	got := commentScoreSQL(commentSortControversial)
+	for _, part := range []string{"COALESCE(POW(", "LIMIT 1 OFFSET 0", "LIMIT 1 OFFSET 1", "+ 0E0"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("controversial score = %q, want it to contain %q", got, part)
+		}
+	}
+}
+
+func TestCommentPageParams(t *testing.T) {
+	cursor := commentCursor{Time: 1, ID: 2}
+	tests := []struct {
+		query     string
+		status    int
+		mode      string
+		limit     int
+		hasCursor bool
+	}{
+		{"", fiber.StatusOK, commentSortOldest, defaultCommentPageSize, false},
+		{"?sort=top&limit=5", fiber.StatusOK, commentSortTop, 5, false},
+		{"?limit=0", fiber.StatusOK, commentSortOldest, defaultCommentPageSize, false},
+		{"?limit=1000", fiber.StatusOK, commentSortOldest, defaultCommentPageSize, false},
+		{"?sort=newest&cursor=" + cursor.encode(), fiber.StatusOK, commentSortNewest, defaultCommentPageSize, true},
+		{"?sort=best", fiber.StatusBadRequest, "", 0, false},
+		{"?cursor=!!!", fiber.StatusBadRequest, "", 0, false},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		var mode string
+		var limit int
+		var after *commentCursor
+		app.Get("/", func(c *fiber.Ctx) error {
+			var ok bool
+			mode, limit, after, ok = commentPageParams(c)
+			if !ok {
+				return nil
+			}
+			return c.SendStatus(fiber.StatusOK)
+		})
+		resp, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.status || mode != tt.mode || limit != tt.limit || (after != nil) != tt.hasCursor {
+			t.Errorf("%s: status %d, mode %q, limit %d, cursor %v", tt.query, resp.StatusCode, mode, limit, after)
+		}
+		if after != nil && *after != cursor {
+			t.Errorf("%s: cursor = %+v, want %+v", tt.query, *after, cursor)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultDashboardDays = 30
+	maxDashboardDays     = 365
+	dashboardTopPosts    = 5
+	dashboardDayFormat   = "2006-01-02"
+)
+
+type dashboardPostCounts struct {
+	Total     int64 `json:"total"`
+	Published int64 `json:"published"`
+	Hidden    int64 `json:"hidden"`
+	Protected int64 `json:"protected"`
+	Trashed   int64 `json:"trashed"`
+}
+
+// dashboardTotals is a total and the part of it inside the window
+type dashboardTotals struct {
+	Total  int64 `json:"total"`
+	Recent int64 `json:"recent"`
+}
+
+type dashboardTopPost struct {
+	ID        uint   `json:"id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	Views     int64  `json:"views"`
+	Comments  int64  `json:"comments"`
+	Reactions int64  `json:"reactions"`
+}
+
+type dashboardDay struct {
+	Day       string `json:"day"`
+	Posts     int64  `json:"posts"`
+	Views     int64  `json:"views"`
+	Comments  int64  `json:"comments"`
+	Reactions int64  `json:"reactions"`
+}
+
+// dashboardDayCount is one row of a per-day GROUP BY
+type dashboardDayCount struct {
+	Day   string
+	Count int64
+}
+
+// recordPostView adds a view of the post to today's count
+func recordPostView(postID uint) {
+	y, m, d := time.Now().Date()
+	err := database.DB.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("views + 1")}),
+	}).Create(&models.PostDailyView{PostID: postID, Day: time.Date(y, m, d, 0, 0, 0, 0, time.Local), Views: 1}).Error
+	if err != nil {
+		log.Error("--> DashboardController: recordPostView: Failed to record view: ", err)
+	}
+}
+
+// AuthorDashboard returns statistics about the posts of the current user:
+// post counts by status, comment, reaction and view totals, the top posts
+// and daily series over the last days (default 30)
+func AuthorDashboard(c *fiber.Ctx) error {
+	user := middleware.CurrentUser(c)
+	days := c.QueryInt("days", defaultDashboardDays)
+	if days < 1 || days > maxDashboardDays {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "days must be between 1 and 365",
+		})
+	}
+	y, m, d := time.Now().Date()
+	since := time.Date(y, m, d-days+1, 0, 0, 0, 0, time.Local)
+
+	// 휴지통에 있는 포스트는 게시물 수에만 포함
+	ownPosts := database.DB.Model(&models.Post{}).Select("id").Where("user_id = ?", user.ID)
+	commentsOnPosts := func() *gorm.DB {
+		return database.DB.Model(&models.Comment{}).
+			Where("comments.post_id IN (?) AND comments.status = ?", ownPosts, models.CommentApproved)
+	}
+	votesOnPosts := func() *gorm.DB {
+		return database.DB.Model(&models.Vote{}).
+			Joins("JOIN comments ON comments.id = votes.comment_id AND comments.deleted_at IS NULL").
+			Where("comments.post_id IN (?)", ownPosts)
+	}
+	viewsOfPosts := func() *gorm.DB {
+		return database.DB.Model(&models.PostDailyView{}).Where("post_id IN (?)", ownPosts)
+	}
+
+	var posts dashboardPostCounts
+	var comments, reactions, views dashboardTotals
+	var top []dashboardTopPost
+	var postDays, viewDays, commentDays, reactionDays []dashboardDayCount
+
+	queries := []*gorm.DB{
+		database.DB.Unscoped().Model(&models.Post{}).Where("user_id = ?", user.ID).Select(
+			"COUNT(*) AS total, " +
+				"COALESCE(SUM(deleted_at IS NULL AND (hidden = 0 OR hidden IS NULL)), 0) AS published, " +
+				"COALESCE(SUM(deleted_at IS NULL AND hidden = 1), 0) AS hidden, " +
+				"COALESCE(SUM(deleted_at IS NULL AND protected = 1), 0) AS protected, " +
+				"COALESCE(SUM(deleted_at IS NOT NULL), 0) AS trashed").
+			Scan(&posts),
+		commentsOnPosts().Select("COUNT(*) AS total, COALESCE(SUM(comments.created_at >= ?), 0) AS recent", since).
+			Scan(&comments),
+		votesOnPosts().Select("COUNT(*) AS total, COALESCE(SUM(votes.created_at >= ?), 0) AS recent", since).
+			Scan(&reactions),
+		viewsOfPosts().Select("COALESCE(SUM(views), 0) AS total, COALESCE(SUM(IF(day >= ?, views, 0)), 0) AS recent", since).
+			Scan(&views),
+
+		// 기간 내 조회수, 댓글 수 순 상위 포스트
+		database.DB.Model(&models.Post{}).
+			Select("posts.id, posts.title, posts.slug, "+
+				"COALESCE(v.views, 0) AS views, COALESCE(cm.comments, 0) AS comments, COALESCE(r.reactions, 0) AS reactions").
+			Joins("LEFT JOIN (?) AS v ON v.post_id = posts.id", database.DB.Model(&models.PostDailyView{}).
+				Select("post_id, SUM(views) AS views").Where("day >= ?", since).Group("post_id")).
+			Joins("LEFT JOIN (?) AS cm ON cm.post_id = posts.id", database.DB.Model(&models.Comment{}).
+				Select("post_id, COUNT(*) AS comments").Where("created_at >= ? AND status = ?", since, models.CommentApproved).Group("post_id")).
+			Joins("LEFT JOIN (?) AS r ON r.post_id = posts.id", database.DB.Model(&models.Vote{}).
+				Select("comments.post_id, COUNT(*) AS reactions").
+				Joins("JOIN comments ON comments.id = votes.comment_id AND comments.deleted_at IS NULL").
+				Where("votes.created_at >= ?", since).Group("comments.post_id")).
+			Where("posts.user_id = ?", user.ID).
+			Order("views DESC, comments DESC, reactions DESC, posts.id DESC").
+			Limit(dashboardTopPosts).
+			Scan(&top),
+
+		// 차트용 일별 집계
+		database.DB.Model(&models.Post{}).Select("DATE(created_at) AS day, COUNT(*) AS count").
+			Where("user_id = ? AND created_at >= ?", user.ID, since).Group("day").Scan(&postDays),
+		viewsOfPosts().Select("DATE(day) AS day, SUM(views) AS count").
+			Where("day >= ?", since).Group("day").Scan(&viewDays),
+		commentsOnPosts().Select("DATE(comments.created_at) AS day, COUNT(*) AS count").
+			Where("comments.created_at >= ?", since).Group("day").Scan(&commentDays),
+		votesOnPosts().Select("DATE(votes.created_at) AS day, COUNT(*) AS count").
+			Where("votes.created_at >= ?", since).Group("day").Scan(&reactionDays),
+	}
+	for _, query := range queries {
+		if query.Error != nil {
+			log.Error("--> DashboardController: AuthorDashboard: Failed to get statistics: ", query.Error)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Error fetching statistics",
+			})
+		}
+	}
+	if top == nil {
+		top = []dashboardTopPost{}
+	}
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"posts":     posts,
+			"comments":  comments,
+			"reactions": reactions,
+			"views":     views,
+			"top_posts": top,
+			"series":    dashboardSeries(since, days, postDays, viewDays, commentDays, reactionDays),
+		},
+		"meta": fiber.Map{
+			"days":  days,
+			"since": since.Format(dashboardDayFormat),
+		},
+	})
+}
+
+// dashboardSeries lays the per-day counts out on every day of the window,
+// including the days without activity
+func dashboardSeries(since time.Time, days int, posts, views, comments, reactions []dashboardDayCount) []dashboardDay {
+	series := make([]dashboardDay, days)
+	index := make(map[string]int, days)
+	for i := range series {
+		series[i].Day = since.AddDate(0, 0, i).Format(dashboardDayFormat)
+		index[series[i].Day] = i
+	}
+	fill := func(counts []dashboardDayCount, field func(*dashboardDay) *int64) {
+		for _, count := range counts {
+			if len(count.Day) >= len(dashboardDayFormat) {
+				if i, ok := index[count.Day[:len(dashboardDayFormat)]]; ok {
+					*field(&series[i]) = count.Count
+				}
+			}
+		}
+	}
+	fill(posts, func(d *dashboardDay) *int64 { return &d.Posts })
+	fill(views, func(d *dashboardDay) *int64 { return &d.Views })
+	fill(comments, func(d *dashboardDay) *int64 { return &d.Comments })
+	fill(reactions, func(d *dashboardDay) *int64 { return &d.Reactions })
+	return series
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDashboardSeries(t *testing.T) {
+	since := time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC)
+	posts := []dashboardDayCount{{"2024-02-28", 1}}
+	// MySQL DATE() 결과가 시간까지 붙어 와도 날짜만 본다
+	views := []dashboardDayCount{{"2024-02-29T00:00:00Z", 12}, {"2024-03-01", 3}}
+	comments := []dashboardDayCount{{"2024-02-27", 9}, {"2024-03-02", 9}, {"", 9}}
+	reactions := []dashboardDayCount{{"2024-03-01", 4}}
+
+	got := dashboardSeries(since, 3, posts, views, comments, reactions)
+	want := []dashboardDay{
+		{Day: "2024-02-28", Posts: 1},
+		{Day: "2024-02-29", Views: 12},
+		{Day: "2024-03-01", Views: 3, Reactions: 4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dashboardSeries = %+v, want %+v", got, want)
+	}
+}
+
+func TestDashboardSeriesEmpty(t *testing.T) {
+	since := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	got := dashboardSeries(since, 2, nil, nil, nil, nil)
+	want := []dashboardDay{{Day: "2024-12-31"}, {Day: "2025-01-01"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("dashboardSeries = %+v, want %+v", got, want)
+	}
+}
+
+func TestAuthorDashboardDays(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Locals("user", &models.User{ID: 1, Role: models.RoleAuthor})
+		return AuthorDashboard(c)
+	})
+	for _, days := range []string{"0", "-1", "366"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/?days="+days, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusBadRequest {
+			t.Errorf("days=%s: status = %d, want 400", days, resp.StatusCode)
+		}
+	}
+}
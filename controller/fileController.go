@@ -3,6 +3,7 @@ package controller
 import (
 	"math/rand"
 	"mime"
+	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -24,6 +25,17 @@ func RandomString(length int) string {
 
 func SaveFile(c *fiber.Ctx, dirPath string) (string, error) {
 	log.Debug("--> FileController: SaveFile")
+	// Parse the uploaded file from the request
+	file, err := c.FormFile("file")
+	if err != nil {
+		return "", err
+	}
+	return saveUploadedFile(c, file, dirPath)
+}
+
+// saveUploadedFile checks the extension, MIME type and size of an uploaded
+// file and saves it under dirPath with a random prefix
+func saveUploadedFile(c *fiber.Ctx, file *multipart.FileHeader, dirPath string) (string, error) {
 	// Define a list of allowed file extensions
 	allowedExtensions := map[string]bool{
 		".jpg":  true,
@@ -42,12 +54,6 @@ func SaveFile(c *fiber.Ctx, dirPath string) (string, error) {
 		"application/pdf": true,
 	}
 
-	// Parse the uploaded file from the request
-	file, err := c.FormFile("file")
-	if err != nil {
-		return "", err
-	}
-
 	ext := filepath.Ext(file.Filename)
 
 	// Check the file's extension to ensure it's a safe file format
@@ -55,7 +61,8 @@ func SaveFile(c *fiber.Ctx, dirPath string) (string, error) {
 		return "", fiber.NewError(fiber.StatusBadRequest, "unsupported extension")
 	}
 
-	mimeType := mime.TypeByExtension(ext)
+	// text/plain comes with a charset parameter
+	mimeType, _, _ := mime.ParseMediaType(mime.TypeByExtension(ext))
 	if !allowedMimeTypes[mimeType] {
 		return "", fiber.NewError(fiber.StatusBadRequest, "unsupported file format")
 	}
@@ -66,7 +73,7 @@ func SaveFile(c *fiber.Ctx, dirPath string) (string, error) {
 	}
 
 	// Save the file in the directory corresponding to the post ID
-	filename := RandomString(8) + "-" + file.Filename
+	filename := RandomString(8) + "-" + filepath.Base(file.Filename)
 	filePath := dirPath + "/" + filename
 	if err := c.SaveFile(file, filePath); err != nil {
 		return "", err
@@ -170,7 +177,8 @@ func SaveImage(c *fiber.Ctx, dirPath string) (string, error) {
 
 	// Check the file's MIME type to ensure it's an image
 	ext := filepath.Ext(file.Filename)
-	mimeType := mime.TypeByExtension(ext)
+	// text/plain comes with a charset parameter
+	mimeType, _, _ := mime.ParseMediaType(mime.TypeByExtension(ext))
 	if !allowedMimeTypes[mimeType] {
 		return "", fiber.NewError(fiber.StatusBadRequest, "unsupported file format")
 	}
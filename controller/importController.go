@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/archive"
+	"github.com/bloomingFlower/blog-backend/importer"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// MaxImportSize is the largest file ImportPosts accepts
+const MaxImportSize = 100 * 1024 * 1024 // 100 MB
+
+// ImportPosts imports posts from an uploaded Markdown file, a zip of a
+// Hugo/Jekyll repository, a WordPress WXR export or a blog export archive
+func ImportPosts(c *fiber.Ctx) error {
+	userID, err := strconv.ParseUint(c.Locals("userID").(string), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid user ID",
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "file is required",
+		})
+	}
+	if file.Size > MaxImportSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "file size exceeds the limit",
+		})
+	}
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	switch ext {
+	case ".zip", ".xml", ".md", ".markdown":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "unsupported file format",
+		})
+	}
+
+	tmpDir, err := os.MkdirTemp("", "blog-import-")
+	if err != nil {
+		log.Error("--> ImportController: ImportPosts: Failed to create temp dir: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Could not save file",
+		})
+	}
+	defer os.RemoveAll(tmpDir)
+
+	uploadPath := filepath.Join(tmpDir, "upload"+ext)
+	if err := c.SaveFile(file, uploadPath); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Could not save file",
+		})
+	}
+
+	source := uploadPath
+	if ext == ".zip" {
+		source = filepath.Join(tmpDir, "files")
+		if err := importer.ExtractZip(uploadPath, source); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid zip file: " + err.Error(),
+			})
+		}
+	}
+
+	opts := importer.Options{
+		DryRun:        c.FormValue("dry_run") == "true",
+		DefaultUserID: uint(userID),
+		FetchRemote:   c.FormValue("fetch_remote") == "true",
+		BaseURL:       siteBaseURL(),
+	}
+
+	// A zip made by ExportBlog restores comments and about info as well
+	if archive.IsArchive(source) {
+		report, err := archive.Restore(source, opts)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Error restoring archive: " + err.Error(),
+			})
+		}
+		return c.JSON(fiber.Map{
+			"data": report,
+		})
+	}
+
+	docs, err := importer.LoadPath(source)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Error parsing import file: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": importer.Import(docs, opts),
+	})
+}
+
+// ExportBlog streams a zip archive of all posts, their files, comments,
+// votes and about info. The archive can be imported again with ImportPosts.
+func ExportBlog(c *fiber.Ctx) error {
+	filename := fmt.Sprintf("blog-export-%s.zip", time.Now().Format("20060102-150405"))
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := archive.Export(w, "uploads"); err != nil {
+			log.Error("--> ImportController: ExportBlog: Failed to export: ", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
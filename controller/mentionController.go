@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// maxCommentMentions is how many users one comment can mention
+const maxCommentMentions = 10
+
+var (
+	usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,32}$`)
+	mentionPattern  = regexp.MustCompile(`@([A-Za-z0-9_]{3,32})`)
+	// markupPattern matches links and tags, whose text is never a mention
+	markupPattern = regexp.MustCompile(`(?is)<a\b[^>]*>.*?</a>|<[^>]*>`)
+	// mentionLinkPattern matches the links renderMentions makes, so content
+	// sent back by an edit has plain mentions again
+	mentionLinkPattern = regexp.MustCompile(`(?i)<a\b[^>]*href="[^"]*/user/([A-Za-z0-9_]{3,32})"[^>]*>@([A-Za-z0-9_]{3,32})</a>`)
+)
+
+// validUsername reports whether name can be used for @mentions
+func validUsername(name string) bool {
+	return usernamePattern.MatchString(name)
+}
+
+// mentionURL is the profile page a mention links to
+func mentionURL(username string) string {
+	return siteBaseURL() + "/user/" + url.PathEscape(username)
+}
+
+// eachMention calls fn with the position and name of every mention in the
+// text of content, outside links and tags. Names in e-mail addresses are not
+// mentions.
+func eachMention(content string, fn func(start, end int, name string)) {
+	text := 0
+	scan := func(end int) {
+		for _, m := range mentionPattern.FindAllStringSubmatchIndex(content[text:end], -1) {
+			start := text + m[0]
+			if start > 0 {
+				if prev := content[start-1]; prev == '_' || prev == '.' || prev == '@' || prev == '/' ||
+					('0' <= prev && prev <= '9') || ('a' <= prev && prev <= 'z') || ('A' <= prev && prev <= 'Z') {
+					continue
+				}
+			}
+			fn(start, text+m[1], content[text+m[2]:text+m[3]])
+		}
+	}
+	for _, m := range markupPattern.FindAllStringIndex(content, -1) {
+		scan(m[0])
+		text = m[1]
+	}
+	scan(len(content))
+}
+
+// plainMentions turns rendered mention links back into @username
+func plainMentions(content string) string {
+	return mentionLinkPattern.ReplaceAllStringFunc(content, func(link string) string {
+		m := mentionLinkPattern.FindStringSubmatch(link)
+		if !strings.EqualFold(m[1], m[2]) {
+			return link
+		}
+		return "@" + m[2]
+	})
+}
+
+// resolveMentions sets the mentions of the comment from its content. Only
+// users who are not blocked can be mentioned, and commenters never mention
+// themselves.
+func resolveMentions(comment *models.Comment) error {
+	comment.Mentions = nil
+	var names []string
+	seen := make(map[string]bool)
+	eachMention(comment.Content, func(_, _ int, name string) {
+		if key := strings.ToLower(name); !seen[key] && len(names) < maxCommentMentions {
+			seen[key] = true
+			names = append(names, name)
+		}
+	})
+	if len(names) == 0 {
+		return nil
+	}
+
+	var users []models.User
+	if err := database.DB.Select("id", "username").Where("username IN ? AND blocked = ?", names, false).Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		if comment.UserID != nil && *comment.UserID == user.ID {
+			continue
+		}
+		comment.Mentions = append(comment.Mentions, models.CommentMention{
+			UserID:   user.ID,
+			Username: *user.Username,
+		})
+	}
+	return nil
+}
+
+// replaceMentions stores the mentions of an edited comment. Users who were
+// already mentioned are not notified again.
+func replaceMentions(tx *gorm.DB, comment *models.Comment) error {
+	var previous []models.CommentMention
+	if err := tx.Where("comment_id = ?", comment.ID).Find(&previous).Error; err != nil {
+		return err
+	}
+	notified := make(map[uint]*time.Time, len(previous))
+	for _, mention := range previous {
+		notified[mention.UserID] = mention.NotifiedAt
+	}
+	for i := range comment.Mentions {
+		comment.Mentions[i].CommentID = comment.ID
+		comment.Mentions[i].NotifiedAt = notified[comment.Mentions[i].UserID]
+	}
+
+	if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.CommentMention{}).Error; err != nil {
+		return err
+	}
+	if len(comment.Mentions) == 0 {
+		return nil
+	}
+	return tx.Create(&comment.Mentions).Error
+}
+
+// renderMentions links the mentions in the content of the comment to the
+// profiles of the mentioned users
+func renderMentions(comment *models.Comment) {
+	if len(comment.Mentions) == 0 {
+		return
+	}
+	mentioned := make(map[string]bool, len(comment.Mentions))
+	for _, mention := range comment.Mentions {
+		mentioned[strings.ToLower(mention.Username)] = true
+	}
+
+	var b strings.Builder
+	last := 0
+	eachMention(comment.Content, func(start, end int, name string) {
+		if !mentioned[strings.ToLower(name)] {
+			return
+		}
+		b.WriteString(comment.Content[last:start])
+		b.WriteString(`<a href="` + mentionURL(name) + `" class="mention">@` + name + `</a>`)
+		last = end
+	})
+	b.WriteString(comment.Content[last:])
+	comment.Content = b.String()
+}
+
+// notifyMentions notifies the users mentioned in the given comments once the
+// comments are approved. Errors are only logged because the comments are
+// already saved.
+func notifyMentions(commentIDs ...uint) {
+	var mentions []struct {
+		models.CommentMention
+		PostID  uint
+		ActorID *uint
+	}
+	err := database.DB.Model(&models.CommentMention{}).
+		Select("comment_mentions.*, comments.post_id, comments.user_id AS actor_id").
+		Joins("JOIN comments ON comments.id = comment_mentions.comment_id AND comments.deleted_at IS NULL").
+		Joins("JOIN users ON users.id = comment_mentions.user_id AND users.blocked = ?", false).
+		Where("comment_mentions.comment_id IN ? AND comment_mentions.notified_at IS NULL AND comments.status = ?",
+			commentIDs, models.CommentApproved).
+		Scan(&mentions).Error
+	if err != nil {
+		log.Error("--> MentionController: notifyMentions: Failed to get mentions: ", err)
+		return
+	}
+	if len(mentions) == 0 {
+		return
+	}
+
+	now := time.Now()
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, mention := range mentions {
+			notification := models.Notification{
+				UserID:    mention.UserID,
+				Type:      models.NotificationMention,
+				ActorID:   mention.ActorID,
+				PostID:    mention.PostID,
+				CommentID: mention.CommentID,
+			}
+			if err := tx.Create(&notification).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&models.CommentMention{}).
+				Where("comment_id = ? AND user_id = ?", mention.CommentID, mention.UserID).
+				UpdateColumn("notified_at", now).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("--> MentionController: notifyMentions: Failed to notify mentions: ", err)
+	}
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bloomingFlower/blog-backend/models"
+)
+
+func TestEachMention(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"none", "hello world", nil},
+		{"plain", "thanks @kim and @lee_2", []string{"kim", "lee_2"}},
+		{"start of content", "@kim hi", []string{"kim"}},
+		{"inside tags", `<p>@kim</p><strong>@lee</strong>`, []string{"kim", "lee"}},
+		{"too short", "@ab hi", nil},
+		{"email address", "mail kim@example.com or a.@lee", nil},
+		{"after a letter or digit", "x@kim 1@lee", nil},
+		{"double at", "@@kim", nil},
+		{"path", "see /@kim", nil},
+		{"in link text", `<a href="https://x.test">@kim</a> @lee`, []string{"lee"}},
+		{"in attributes", `<img alt="@kim" src="a.png"> @lee`, []string{"lee"}},
+		{"punctuation after", "@kim, @lee!", []string{"kim", "lee"}},
+		{"korean text around", "안녕 @kim 님", []string{"kim"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			eachMention(tt.content, func(start, end int, name string) {
+				if tt.content[start:end] != "@"+name {
+					t.Errorf("content[%d:%d] = %q, want @%s", start, end, tt.content[start:end], name)
+				}
+				got = append(got, name)
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("eachMention(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMentions(t *testing.T) {
+	t.Setenv("BASE_URL", "https://blog.example.com/")
+	tests := []struct {
+		name      string
+		content   string
+		mentioned []string
+		want      string
+	}{
+		{"no mentions", "hi @kim", nil, "hi @kim"},
+		{"mentioned user", "<p>hi @kim</p>", []string{"kim"},
+			`<p>hi <a href="https://blog.example.com/user/kim" class="mention">@kim</a></p>`},
+		{"case insensitive", "hi @Kim", []string{"kim"},
+			`hi <a href="https://blog.example.com/user/Kim" class="mention">@Kim</a>`},
+		{"unknown users stay plain", "@kim @nobody", []string{"kim"},
+			`<a href="https://blog.example.com/user/kim" class="mention">@kim</a> @nobody`},
+		{"repeated", "@kim @kim", []string{"kim"},
+			`<a href="https://blog.example.com/user/kim" class="mention">@kim</a> <a href="https://blog.example.com/user/kim" class="mention">@kim</a>`},
+		{"links are left alone", `<a href="https://x.test">@kim</a>`, []string{"kim"}, `<a href="https://x.test">@kim</a>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := models.Comment{Content: tt.content}
+			for _, name := range tt.mentioned {
+				comment.Mentions = append(comment.Mentions, models.CommentMention{Username: name})
+			}
+			renderMentions(&comment)
+			if comment.Content != tt.want {
+				t.Errorf("renderMentions(%q) = %q, want %q", tt.content, comment.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainMentions(t *testing.T) {
+	t.Setenv("BASE_URL", "https://blog.example.com")
+	comment := models.Comment{
+		Content:  "<p>hi @kim and @lee</p>",
+		Mentions: []models.CommentMention{{Username: "kim"}, {Username: "lee"}},
+	}
+	renderMentions(&comment)
+	if got := plainMentions(comment.Content); got != "<p>hi @kim and @lee</p>" {
+		t.Errorf("plainMentions(renderMentions) = %q", got)
+	}
+
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`<a href="https://blog.example.com/user/kim" class="mention">@kim</a>`, "@kim"},
+		{`<a href="/user/KIM">@kim</a>`, "@kim"},
+		// 링크와 텍스트의 사용자가 다르면 그대로 둠
+		{`<a href="https://evil.test/user/kim">@lee</a>`, `<a href="https://evil.test/user/kim">@lee</a>`},
+		{`<a href="https://x.test">@kim</a>`, `<a href="https://x.test">@kim</a>`},
+	}
+	for _, tt := range tests {
+		if got := plainMentions(tt.content); got != tt.want {
+			t.Errorf("plainMentions(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestValidUsername(t *testing.T) {
+	for name, want := range map[string]bool{
+		"kim":                               true,
+		"Kim_Minji_2":                       true,
+		"ab":                                false,
+		"kim.minji":                         false,
+		"김민지":                               false,
+		"abcdefghijklmnopqrstuvwxyz0123456": false,
+	} {
+		if got := validUsername(name); got != want {
+			t.Errorf("validUsername(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// siteBaseURL returns the public URL of the blog
+func siteBaseURL() string {
+	baseURL := os.Getenv("BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8008"
+	}
+	return strings.TrimSuffix(baseURL, "/")
+}
+
+// findPublicPost finds a visible post by its ID or slug
+func findPublicPost(idOrSlug string) (models.Post, error) {
+	var post models.Post
+	query := database.DB.Preload("User").Where("hidden = ? OR hidden IS NULL", false)
+	if id, err := strconv.Atoi(idOrSlug); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("slug = ?", idOrSlug)
+	}
+	err := query.First(&post).Error
+	return post, err
+}
+
+// postMetaPage holds the values rendered into postMetaTemplate
+type postMetaPage struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	Author      string
+	Published   string
+	Modified    string
+	Tags        []string
+	Category    string
+	SiteName    string
+	OEmbedURL   string
+	JSONLD      template.JS
+}
+
+var postMetaTemplate = template.Must(template.New("post-meta").Parse(`<!DOCTYPE html>
+<html lang="ko">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta name="description" content="{{.Description}}">
+<link rel="canonical" href="{{.URL}}">
+<link rel="alternate" type="application/json+oembed" href="{{.OEmbedURL}}" title="{{.Title}}">
+<meta property="og:type" content="article">
+<meta property="og:site_name" content="{{.SiteName}}">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta property="og:url" content="{{.URL}}">
+<meta property="og:image" content="{{.Image}}">
+<meta property="og:image:width" content="1200">
+<meta property="og:image:height" content="630">
+<meta property="article:published_time" content="{{.Published}}">
+<meta property="article:modified_time" content="{{.Modified}}">
+<meta property="article:author" content="{{.Author}}">
+{{- if .Category}}
+<meta property="article:section" content="{{.Category}}">
+{{- end}}
+{{- range .Tags}}
+<meta property="article:tag" content="{{.}}">
+{{- end}}
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+<meta name="twitter:image" content="{{.Image}}">
+<script type="application/ld+json">{{.JSONLD}}</script>
+<meta http-equiv="refresh" content="0; url={{.URL}}">
+</head>
+<body>
+<p><a href="{{.URL}}">{{.Title}}</a></p>
+<script>window.location.replace({{.URL}});</script>
+</body>
+</html>
+`))
+
+// PostMeta renders a minimal HTML document with social preview meta tags for
+// crawlers that do not run JavaScript, then redirects browsers to the SPA
+func PostMeta(c *fiber.Ctx) error {
+	post, err := findPublicPost(c.Params("key"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+
+	baseURL := siteBaseURL()
+	postURL := fmt.Sprintf("%s/post/%d", baseURL, post.ID)
+	imageURL := fmt.Sprintf("%s/api/v1/post/%d/og-image", baseURL, post.ID)
+	author := strings.TrimSpace(post.User.FirstName + " " + post.User.LastName)
+	modified := post.CreatedAt
+	if post.UpdatedAt != nil {
+		modified = *post.UpdatedAt
+	}
+
+	page := postMetaPage{
+		Title:       post.Title,
+		Description: postSummary(post, 200),
+		URL:         postURL,
+		Image:       imageURL,
+		Author:      author,
+		Published:   post.CreatedAt.Format(time.RFC3339),
+		Modified:    modified.Format(time.RFC3339),
+		Category:    post.Category,
+		SiteName:    ogSiteName,
+		OEmbedURL:   baseURL + "/api/v1/oembed?url=" + url.QueryEscape(postURL),
+	}
+	for _, tag := range strings.Split(post.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			page.Tags = append(page.Tags, tag)
+		}
+	}
+
+	jsonLD, err := json.Marshal(map[string]interface{}{
+		"@context":         "https://schema.org",
+		"@type":            "BlogPosting",
+		"headline":         truncateString(post.Title, 110),
+		"description":      page.Description,
+		"image":            imageURL,
+		"url":              postURL,
+		"mainEntityOfPage": map[string]string{"@type": "WebPage", "@id": postURL},
+		"datePublished":    page.Published,
+		"dateModified":     page.Modified,
+		"author":           map[string]string{"@type": "Person", "name": author},
+		"publisher":        map[string]string{"@type": "Organization", "name": ogSiteName},
+		"keywords":         strings.Join(page.Tags, ","),
+		"articleSection":   post.Category,
+	})
+	if err != nil {
+		log.Error("--> MetaController: PostMeta: Failed to marshal JSON-LD: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error generating page",
+		})
+	}
+	page.JSONLD = template.JS(jsonLD)
+
+	var sb strings.Builder
+	if err := postMetaTemplate.Execute(&sb, page); err != nil {
+		log.Error("--> MetaController: PostMeta: Failed to render template: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error generating page",
+		})
+	}
+
+	c.Set("Content-Type", "text/html; charset=utf-8")
+	c.Set("Cache-Control", "public, max-age=600")
+	return c.SendString(sb.String())
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+)
+
+func TestPostMetaTemplateEscapes(t *testing.T) {
+	page := postMetaPage{
+		Title:       `Tom & "Jerry" <script>alert(1)</script>`,
+		Description: `a "quoted" description`,
+		URL:         "https://blog.example.com/post/1",
+		Image:       "https://blog.example.com/api/v1/post/1/og-image",
+		Tags:        []string{"go", "<b>web</b>"},
+		Category:    "notes",
+		JSONLD:      template.JS(`{"headline":"Tom & \"Jerry\" <script>"}`),
+	}
+	var sb strings.Builder
+	if err := postMetaTemplate.Execute(&sb, page); err != nil {
+		t.Fatal(err)
+	}
+	html := sb.String()
+
+	for _, want := range []string{
+		`<title>Tom &amp; &#34;Jerry&#34; &lt;script&gt;alert(1)&lt;/script&gt;</title>`,
+		`<meta property="og:description" content="a &#34;quoted&#34; description">`,
+		`<meta property="article:section" content="notes">`,
+		`<meta property="article:tag" content="&lt;b&gt;web&lt;/b&gt;">`,
+		`<link rel="canonical" href="https://blog.example.com/post/1">`,
+		`window.location.replace("https://blog.example.com/post/1")`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("page does not contain %s", want)
+		}
+	}
+	if strings.Contains(html, "<script>alert(1)") {
+		t.Error("title is not escaped")
+	}
+}
@@ -0,0 +1,447 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/spam"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const (
+	moderationPageSize = 50
+	maxModerationBatch = 500
+)
+
+// Labels the spam filter was trained with, stored in Comment.TrainedAs
+const (
+	trainedSpam = "spam"
+	trainedHam  = "ham"
+)
+
+var commentLinkPattern = regexp.MustCompile(`(?i)(https?://|www\.|<a\s)`)
+
+// spamFilter scores new comments. It is built from the environment on first use.
+var spamFilter = sync.OnceValue(func() *spam.Pipeline {
+	return spam.FromEnv(database.DB)
+})
+
+// spamComment describes a stored comment to the spam filter
+func spamComment(comment models.Comment) *spam.Comment {
+	sc := &spam.Comment{
+		PostID:    comment.PostID,
+		Reply:     comment.ParentID != nil,
+		Content:   comment.Content,
+		IP:        comment.IPAddress, // 예전 댓글만 남아 있음
+		Permalink: fmt.Sprintf("%s/post/%d", siteBaseURL(), comment.PostID),
+	}
+	if comment.UserID != nil {
+		sc.UserID = *comment.UserID
+	}
+	return sc
+}
+
+// commentPolicyFor returns the policy of the post, or the global policy when
+// the post has none
+func commentPolicyFor(postID uint) models.CommentPolicy {
+	var policy models.CommentPolicy
+	database.DB.Where("post_id IN ?", []uint{postID, 0}).Order("post_id DESC").First(&policy)
+	return policy
+}
+
+// holdNewComment sets the moderation status of a new comment from its spam
+// score and the policy of its post. Comments of moderators are never held.
+func holdNewComment(c *fiber.Ctx, comment *models.Comment) {
+	comment.Status = models.CommentApproved
+	comment.HoldReason = ""
+	comment.SpamScore = 0
+	comment.TrainedAs = ""
+	comment.ModeratedBy = nil
+	comment.ModeratedAt = nil
+	user := middleware.CurrentUser(c)
+	if user.Can(models.PermModerateComments) {
+		return
+	}
+
+	// 봇만 채우는 숨겨진 website 필드
+	var form struct {
+		Website string `json:"website" form:"website"`
+	}
+	c.BodyParser(&form)
+	sc := spamComment(*comment)
+	sc.Honeypot = form.Website
+	sc.IP = c.IP()
+	sc.UserAgent = c.Get(fiber.HeaderUserAgent)
+	sc.Referrer = c.Get(fiber.HeaderReferer)
+	if user != nil {
+		sc.Author = user.FirstName
+		sc.AuthorEmail = user.Email
+	}
+	result := spamFilter().Evaluate(c.Context(), sc)
+	comment.SpamScore = result.Score
+	switch result.Action {
+	case spam.ActionReject:
+		comment.Status = models.CommentSpam
+		comment.HoldReason = result.Reason()
+		return
+	case spam.ActionHold:
+		comment.Status = models.CommentPending
+		comment.HoldReason = result.Reason()
+		return
+	}
+
+	policy := commentPolicyFor(comment.PostID)
+	switch {
+	case policy.HoldAll:
+		comment.HoldReason = "all comments are reviewed"
+	case policy.HoldAnonymous && comment.UserID == nil:
+		comment.HoldReason = "anonymous comment"
+	case policy.HoldLinks && commentLinkPattern.MatchString(comment.Content):
+		comment.HoldReason = "contains a link"
+	}
+	if comment.HoldReason != "" {
+		comment.Status = models.CommentPending
+	}
+}
+
+// visibleComments limits query to approved comments and the pending comments
+// of the current user. Moderators see every pending comment.
+func visibleComments(query *gorm.DB, user *models.User) *gorm.DB {
+	if user.Can(models.PermModerateComments) {
+		return query.Where("comments.status IN ?", []string{models.CommentApproved, models.CommentPending})
+	}
+	if user != nil {
+		return query.Where("(comments.status = ? OR (comments.status = ? AND comments.user_id = ?))",
+			models.CommentApproved, models.CommentPending, user.ID)
+	}
+	return query.Where("comments.status = ?", models.CommentApproved)
+}
+
+// createdCommentMessage tells the commenter whether the comment is public
+// yet. Spam gets the same answer as held comments.
+func createdCommentMessage(comment models.Comment, published string) string {
+	if comment.Status != models.CommentApproved {
+		return "Comment is awaiting moderation"
+	}
+	return published
+}
+
+// requestedIPHash returns the IP hash moderators filter by, given as the
+// ip_hash param or hashed from the ip param
+func requestedIPHash(c *fiber.Ctx) string {
+	if ip := c.Query("ip"); ip != "" {
+		return util.HashIP(ip)
+	}
+	return c.Query("ip_hash")
+}
+
+// GetModerationQueue lists comments by moderation status, pending by default,
+// oldest first
+func GetModerationQueue(c *fiber.Ctx) error {
+	status := c.Query("status", models.CommentPending)
+	if !models.ValidCommentStatus(status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid status",
+		})
+	}
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	query := database.DB.Table("comments").
+		Where("comments.status = ? AND comments.deleted_at IS NULL", status)
+	if postID := c.QueryInt("post_id"); postID > 0 {
+		query = query.Where("comments.post_id = ?", postID)
+	}
+	if ipHash := requestedIPHash(c); ipHash != "" {
+		query = query.Where("comments.ip_hash = ?", ipHash)
+	}
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var items []struct {
+		models.Comment
+		PostTitle string `json:"post_title"`
+		// 같은 IP에서 온 댓글을 찾을 수 있도록 검토자에게만 공개
+		Fingerprint string `json:"ip_hash"`
+	}
+	err := query.Select("comments.*, posts.title AS post_title, comments.ip_hash AS fingerprint").
+		Joins("LEFT JOIN posts ON posts.id = comments.post_id").
+		Preload("User", func(db *gorm.DB) *gorm.DB {
+			return db.Select("ID", "FirstName", "Picture")
+		}).
+		Order("comments.created_at").
+		Offset((page - 1) * moderationPageSize).Limit(moderationPageSize).
+		Find(&items).Error
+	if err != nil {
+		log.Error("--> ModerationController: GetModerationQueue: Failed to get comments: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get comments",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": items,
+		"meta": fiber.Map{
+			"total":     total,
+			"page":      page,
+			"last_page": (total + moderationPageSize - 1) / moderationPageSize,
+		},
+	})
+}
+
+// ModerateComments sets the moderation status of many comments at once
+func ModerateComments(c *fiber.Ctx) error {
+	var data struct {
+		IDs    []uint `json:"ids"`
+		Status string `json:"status"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body",
+		})
+	}
+	if !models.ValidCommentStatus(data.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "status must be pending, approved, spam or rejected",
+		})
+	}
+	if len(data.IDs) == 0 || len(data.IDs) > maxModerationBatch {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "ids must list between 1 and " + strconv.Itoa(maxModerationBatch) + " comments",
+		})
+	}
+
+	// 학습을 위해 변경 전 댓글 조회
+	var comments []models.Comment
+	if err := database.DB.Where("id IN ?", data.IDs).Find(&comments).Error; err != nil {
+		log.Error("--> ModerationController: ModerateComments: Failed to get comments: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to moderate comments",
+		})
+	}
+
+	user := middleware.CurrentUser(c)
+	result := database.DB.Model(&models.Comment{}).Where("id IN ?", data.IDs).Updates(map[string]interface{}{
+		"status":       data.Status,
+		"hold_reason":  "",
+		"moderated_by": user.ID,
+		"moderated_at": time.Now(),
+	})
+	if result.Error != nil {
+		log.Error("--> ModerationController: ModerateComments: Failed to update comments: ", result.Error)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to moderate comments",
+		})
+	}
+	go trainSpamFilter(comments, data.Status)
+	if data.Status == models.CommentApproved {
+		notifyComments(data.IDs...)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Comments moderated successfully",
+		"meta": fiber.Map{
+			"updated": result.RowsAffected,
+		},
+	})
+}
+
+// trainSpamFilter teaches the spam filter the decision of a moderator. Spam
+// and approved comments are learned once; changing the decision forgets the
+// earlier one first.
+func trainSpamFilter(comments []models.Comment, status string) {
+	label := ""
+	switch status {
+	case models.CommentSpam:
+		label = trainedSpam
+	case models.CommentApproved:
+		label = trainedHam
+	default:
+		return
+	}
+
+	ctx := context.Background()
+	filter := spamFilter()
+	for _, comment := range comments {
+		if comment.TrainedAs == label {
+			continue
+		}
+		sc := spamComment(comment)
+		if comment.TrainedAs != "" {
+			filter.Forget(ctx, sc, comment.TrainedAs == trainedSpam)
+		}
+		filter.Learn(ctx, sc, label == trainedSpam)
+		database.DB.Model(&comment).UpdateColumn("trained_as", label)
+	}
+}
+
+// GetCommentPolicy returns the global comment policy
+func GetCommentPolicy(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"data": commentPolicyFor(0),
+	})
+}
+
+// UpdateCommentPolicy changes the global comment policy
+func UpdateCommentPolicy(c *fiber.Ctx) error {
+	return saveCommentPolicy(c, 0)
+}
+
+// findPolicyPost loads the post from the :id param and checks that the current
+// user may change its comment policy: a moderator or someone who manages the post
+func findPolicyPost(c *fiber.Ctx) (models.Post, bool) {
+	var post models.Post
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "postID must be a valid integer",
+		})
+		return post, false
+	}
+	if err := database.DB.First(&post, id).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Post not found",
+		})
+		return post, false
+	}
+	user := middleware.CurrentUser(c)
+	if !canManagePost(user, post) && !user.Can(models.PermModerateComments) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "You are not authorized to change the comment policy of this post",
+		})
+		return post, false
+	}
+	return post, true
+}
+
+// GetPostCommentPolicy returns the policy that applies to a post. inherited
+// is true when it is the global policy.
+func GetPostCommentPolicy(c *fiber.Ctx) error {
+	post, ok := findPolicyPost(c)
+	if !ok {
+		return nil
+	}
+
+	policy := commentPolicyFor(post.ID)
+	return c.JSON(fiber.Map{
+		"data": policy,
+		"meta": fiber.Map{
+			"inherited": policy.PostID != post.ID,
+		},
+	})
+}
+
+// UpdatePostCommentPolicy sets a policy for one post instead of the global one
+func UpdatePostCommentPolicy(c *fiber.Ctx) error {
+	post, ok := findPolicyPost(c)
+	if !ok {
+		return nil
+	}
+	return saveCommentPolicy(c, post.ID)
+}
+
+// DeletePostCommentPolicy makes the post follow the global policy again
+func DeletePostCommentPolicy(c *fiber.Ctx) error {
+	post, ok := findPolicyPost(c)
+	if !ok {
+		return nil
+	}
+
+	if err := database.DB.Where("post_id = ?", post.ID).Delete(&models.CommentPolicy{}).Error; err != nil {
+		log.Error("--> ModerationController: DeletePostCommentPolicy: Failed to delete policy: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete comment policy",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Comment policy deleted successfully",
+	})
+}
+
+func saveCommentPolicy(c *fiber.Ctx, postID uint) error {
+	var data struct {
+		HoldAll       bool `json:"hold_all"`
+		HoldAnonymous bool `json:"hold_anonymous"`
+		HoldLinks     bool `json:"hold_links"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body",
+		})
+	}
+
+	var policy models.CommentPolicy
+	database.DB.Where("post_id = ?", postID).First(&policy)
+	policy.PostID = postID
+	policy.HoldAll = data.HoldAll
+	policy.HoldAnonymous = data.HoldAnonymous
+	policy.HoldLinks = data.HoldLinks
+	if err := database.DB.Save(&policy).Error; err != nil {
+		log.Error("--> ModerationController: saveCommentPolicy: Failed to save policy: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save comment policy",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Comment policy saved successfully",
+		"data":    policy,
+	})
+}
+
+// GetCommentAudit lists recorded comment actions, newest first, optionally
+// for one post or comment
+func GetCommentAudit(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	query := database.DB.Model(&models.CommentAudit{})
+	if postID := c.QueryInt("post_id"); postID > 0 {
+		query = query.Where("post_id = ?", postID)
+	}
+	if commentID := c.QueryInt("comment_id"); commentID > 0 {
+		query = query.Where("comment_id = ?", commentID)
+	}
+	if ipHash := requestedIPHash(c); ipHash != "" {
+		query = query.Where("ip_hash = ?", ipHash)
+	}
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var audits []models.CommentAudit
+	err := query.Preload("Actor", func(db *gorm.DB) *gorm.DB {
+		return db.Select("ID", "FirstName", "Email")
+	}).Order("created_at DESC, id DESC").
+		Offset((page - 1) * moderationPageSize).Limit(moderationPageSize).
+		Find(&audits).Error
+	if err != nil {
+		log.Error("--> ModerationController: GetCommentAudit: Failed to get audit log: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get audit log",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": audits,
+		"meta": fiber.Map{
+			"total":     total,
+			"page":      page,
+			"last_page": (total + moderationPageSize - 1) / moderationPageSize,
+		},
+	})
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// useDryRunDB points database.DB at a connection that only builds queries;
+// every lookup finds nothing
+func useDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/blog",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = old })
+	return db
+}
+
+func TestVisibleComments(t *testing.T) {
+	db := useDryRunDB(t)
+	tests := []struct {
+		name string
+		user *models.User
+		want string
+		vars []interface{}
+	}{
+		{"anonymous", nil, "comments.status = ?", []interface{}{models.CommentApproved}},
+		{"commenter", &models.User{ID: 3, Role: models.RoleCommenter},
+			"(comments.status = ? OR (comments.status = ? AND comments.user_id = ?))",
+			[]interface{}{models.CommentApproved, models.CommentPending, uint(3)}},
+		{"moderator", &models.User{ID: 1, Role: models.RoleAdmin}, "comments.status IN (?,?)",
+			[]interface{}{models.CommentApproved, models.CommentPending}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var comments []models.Comment
+			stmt := visibleComments(db.Model(&models.Comment{}), tt.user).Find(&comments).Statement
+			if sql := stmt.SQL.String(); !strings.Contains(sql, tt.want) {
+				t.Errorf("SQL = %q, want it to contain %q", sql, tt.want)
+			}
+			if len(stmt.Vars) != len(tt.vars) {
+				t.Fatalf("vars = %v, want %v", stmt.Vars, tt.vars)
+			}
+			for i, v := range tt.vars {
+				if stmt.Vars[i] != v {
+					t.Errorf("vars[%d] = %v, want %v", i, stmt.Vars[i], v)
+				}
+			}
+		})
+	}
+}
+
+func TestCreatedCommentMessage(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{models.CommentApproved, "Comment created successfully"},
+		{models.CommentPending, "Comment is awaiting moderation"},
+		// 스팸도 검토 대기와 같은 응답을 받는다
+		{models.CommentSpam, "Comment is awaiting moderation"},
+	}
+	for _, tt := range tests {
+		got := createdCommentMessage(models.Comment{Status: tt.status}, "Comment created successfully")
+		if got != tt.want {
+			t.Errorf("createdCommentMessage(%s) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestHoldNewComment(t *testing.T) {
+	useDryRunDB(t)
+	userID := uint(3)
+	tests := []struct {
+		name       string
+		user       *models.User
+		body       string
+		comment    models.Comment
+		wantStatus string
+	}{
+		{"approved", nil, `{}`, models.Comment{Content: "Nice post"}, models.CommentApproved},
+		{"honeypot", nil, `{"website":"http://spam.example"}`, models.Comment{Content: "Nice post"}, models.CommentSpam},
+		// 관리자의 댓글은 스팸 검사도 하지 않는다
+		{"moderator", &models.User{ID: 1, Role: models.RoleAdmin}, `{"website":"x"}`,
+			models.Comment{Content: "Nice post", UserID: &userID, Status: models.CommentSpam, HoldReason: "old"}, models.CommentApproved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			comment := tt.comment
+			app.Post("/", func(c *fiber.Ctx) error {
+				c.Locals("user", tt.user)
+				holdNewComment(c, &comment)
+				return nil
+			})
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if comment.Status != tt.wantStatus {
+				t.Errorf("Status = %q (%s), want %q", comment.Status, comment.HoldReason, tt.wantStatus)
+			}
+			if tt.wantStatus == models.CommentApproved && comment.HoldReason != "" {
+				t.Errorf("HoldReason = %q, want none", comment.HoldReason)
+			}
+		})
+	}
+}
+
+func TestRequestedIPHash(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", ""},
+		{"?ip_hash=abc", "abc"},
+		{"?ip=203.0.113.7", util.HashIP("203.0.113.7")},
+		// ip가 있으면 ip_hash보다 우선
+		{"?ip=203.0.113.7&ip_hash=abc", util.HashIP("203.0.113.7")},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		var got string
+		app.Get("/", func(c *fiber.Ctx) error {
+			got = requestedIPHash(c)
+			return nil
+		})
+		if _, err := app.Test(httptest.NewRequest("GET", "/"+tt.query, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("requestedIPHash(%s) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package controller
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/mailer"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const notificationPageSize = 30
+
+// mailQueue is the notification email queue, nil when SMTP is not configured
+var mailQueue = sync.OnceValue(func() *mailer.Queue {
+	return mailer.FromEnv(database.DB)
+})
+
+// unsubscribePage answers people who follow the unsubscribe link of an email.
+// It asks first and only its form unsubscribes, so mail scanners that open
+// the link change nothing.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>` +
+	`<html lang="{{.Lang}}"><head><meta charset="utf-8"><title>{{.Title}}</title></head><body>` +
+	`{{if .Done}}<p>{{.Done}}</p>{{else}}<form method="post"><p>{{.Question}}</p>` +
+	`<button type="submit">{{.Button}}</button></form>{{end}}</body></html>`))
+
+type unsubscribeText struct {
+	Lang, Title, Question, Button, Done string
+}
+
+var unsubscribeTexts = map[string]unsubscribeText{
+	"ko": {
+		Lang:     "ko",
+		Title:    "수신 거부",
+		Question: "댓글·답글 알림 메일을 더 이상 받지 않으시겠습니까?",
+		Button:   "수신 거부",
+		Done:     "댓글·답글 알림 메일을 더 이상 보내지 않습니다.",
+	},
+	"en": {
+		Lang:     "en",
+		Title:    "Unsubscribe",
+		Question: "Stop receiving comment and reply notification emails?",
+		Button:   "Unsubscribe",
+		Done:     "You will no longer receive comment and reply notification emails.",
+	},
+}
+
+// notifyComments notifies about newly approved comments: the mentioned users,
+// the post author and, for replies, the parent commenter. Each comment is
+// notified once. Errors are only logged because the comments are already
+// saved.
+func notifyComments(commentIDs ...uint) {
+	notifyMentions(commentIDs...)
+
+	var comments []models.Comment
+	err := database.DB.Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Select("ID", "Username", "FirstName")
+	}).Preload("Mentions").
+		Where("id IN ? AND status = ? AND notified_at IS NULL", commentIDs, models.CommentApproved).
+		Find(&comments).Error
+	if err != nil {
+		log.Error("--> NotificationController: notifyComments: Failed to get comments: ", err)
+		return
+	}
+	for _, comment := range comments {
+		claim := database.DB.Model(&models.Comment{}).
+			Where("id = ? AND notified_at IS NULL", comment.ID).
+			UpdateColumn("notified_at", time.Now())
+		if claim.Error != nil {
+			log.Error("--> NotificationController: notifyComments: Failed to update comment: ", claim.Error)
+			continue
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+		if err := notifyComment(comment); err != nil {
+			log.Error("--> NotificationController: notifyComments: Failed to notify comment: ", err)
+		}
+	}
+}
+
+// notifyComment notifies the post author and, for a reply, the parent
+// commenter in the app and by email unless they opted out
+func notifyComment(comment models.Comment) error {
+	var post models.Post
+	if err := database.DB.Select("id", "user_id", "title").First(&post, comment.PostID).Error; err != nil {
+		return err
+	}
+
+	// 한 사람에게는 한 번만 알리고, 댓글 작성자 자신에게는 알리지 않음
+	var recipients []uint
+	kinds := make(map[uint]string)
+	add := func(userID uint, kind string) {
+		if _, ok := kinds[userID]; ok || (comment.UserID != nil && *comment.UserID == userID) {
+			return
+		}
+		kinds[userID] = kind
+		recipients = append(recipients, userID)
+	}
+	if comment.ParentID != nil {
+		var parent models.Comment
+		if database.DB.Select("id", "user_id").First(&parent, *comment.ParentID).Error == nil && parent.UserID != nil {
+			add(*parent.UserID, models.NotificationReply)
+		}
+	}
+	add(post.UserID, models.NotificationComment)
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	mentioned := make(map[uint]bool, len(comment.Mentions))
+	for _, mention := range comment.Mentions {
+		mentioned[mention.UserID] = true
+	}
+	var users []models.User
+	if err := database.DB.Where("id IN ? AND blocked = ?", recipients, false).Find(&users).Error; err != nil {
+		return err
+	}
+	queue := mailQueue()
+	for _, user := range users {
+		kind := kinds[user.ID]
+		// 멘션된 사용자는 멘션 알림을 이미 받음
+		if !mentioned[user.ID] {
+			notification := models.Notification{
+				UserID:    user.ID,
+				Type:      kind,
+				ActorID:   comment.UserID,
+				PostID:    post.ID,
+				CommentID: comment.ID,
+			}
+			if err := database.DB.Create(&notification).Error; err != nil {
+				return err
+			}
+		}
+		if queue == nil || user.EmailOptOut || user.Email == "" {
+			continue
+		}
+		if err := emailComment(queue, user, kind, post, comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emailComment queues the email about a comment on the user's post or a
+// reply to the user's comment
+func emailComment(queue *mailer.Queue, user models.User, kind string, post models.Post, comment models.Comment) error {
+	name := mailer.TemplateComment
+	if kind == models.NotificationReply {
+		name = mailer.TemplateReply
+	}
+	actor := comment.User.FirstName
+	if actor == "" && comment.User.Username != nil {
+		actor = *comment.User.Username
+	}
+	unsubscribe := siteBaseURL() + "/api/v1/unsubscribe?token=" + url.QueryEscape(util.UnsubscribeToken(user.ID))
+
+	message, err := mailer.Render(name, user.Language, mailer.CommentNotice{
+		RecipientName:  user.FirstName,
+		ActorName:      actor,
+		PostTitle:      post.Title,
+		Excerpt:        truncateString(cleanHTMLContent(comment.Content), 200),
+		URL:            fmt.Sprintf("%s/post/%d#comment-%d", siteBaseURL(), post.ID, comment.ID),
+		UnsubscribeURL: unsubscribe,
+	})
+	if err != nil {
+		return err
+	}
+	message.To = user.Email
+	message.Unsubscribe = unsubscribe
+	return queue.Enqueue(&user.ID, message)
+}
+
+// GetNotifications lists the notifications of the current user, newest
+// first. unread=true leaves out the ones already read.
+func GetNotifications(c *fiber.Ctx) error {
+	user := middleware.CurrentUser(c)
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	query := database.DB.Model(&models.Notification{}).Where("notifications.user_id = ?", user.ID)
+	var unread int64
+	query.Session(&gorm.Session{}).Where("notifications.read_at IS NULL").Count(&unread)
+	if c.QueryBool("unread") {
+		query = query.Where("notifications.read_at IS NULL")
+	}
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var items []struct {
+		models.Notification
+		PostTitle string `json:"post_title"`
+	}
+	err := query.Select("notifications.*, posts.title AS post_title").
+		Joins("LEFT JOIN posts ON posts.id = notifications.post_id").
+		Preload("Actor", func(db *gorm.DB) *gorm.DB {
+			return db.Select("ID", "Username", "FirstName", "Picture")
+		}).
+		Order("notifications.created_at DESC, notifications.id DESC").
+		Offset((page - 1) * notificationPageSize).Limit(notificationPageSize).
+		Find(&items).Error
+	if err != nil {
+		log.Error("--> NotificationController: GetNotifications: Failed to get notifications: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get notifications",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": items,
+		"meta": fiber.Map{
+			"total":     total,
+			"unread":    unread,
+			"page":      page,
+			"last_page": (total + notificationPageSize - 1) / notificationPageSize,
+		},
+	})
+}
+
+// ReadNotifications marks the notifications with the given ids as read, or
+// all of them when no ids are given
+func ReadNotifications(c *fiber.Ctx) error {
+	var data struct {
+		IDs []uint `json:"ids"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&data); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to parse request body",
+			})
+		}
+	}
+
+	query := database.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", middleware.CurrentUser(c).ID)
+	if len(data.IDs) > 0 {
+		query = query.Where("id IN ?", data.IDs)
+	}
+	result := query.UpdateColumn("read_at", time.Now())
+	if result.Error != nil {
+		log.Error("--> NotificationController: ReadNotifications: Failed to update notifications: ", result.Error)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update notifications",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notifications marked as read",
+		"meta": fiber.Map{
+			"updated": result.RowsAffected,
+		},
+	})
+}
+
+// GetNotificationSettings returns the email notification settings of the
+// current user
+func GetNotificationSettings(c *fiber.Ctx) error {
+	user := middleware.CurrentUser(c)
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"email":         user.Email,
+			"email_opt_out": user.EmailOptOut,
+			"language":      mailer.Language(user.Language),
+		},
+	})
+}
+
+// UpdateNotificationSettings turns notification emails on or off and sets
+// their language
+func UpdateNotificationSettings(c *fiber.Ctx) error {
+	var data struct {
+		EmailOptOut *bool   `json:"email_opt_out"`
+		Language    *string `json:"language"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body",
+		})
+	}
+
+	user := middleware.CurrentUser(c)
+	updates := make(map[string]interface{})
+	if data.EmailOptOut != nil {
+		user.EmailOptOut = *data.EmailOptOut
+		updates["email_opt_out"] = user.EmailOptOut
+	}
+	if data.Language != nil {
+		if *data.Language != "" && mailer.Language(*data.Language) != *data.Language {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "language must be ko or en",
+			})
+		}
+		user.Language = *data.Language
+		updates["language"] = user.Language
+	}
+	if len(updates) > 0 {
+		if err := database.DB.Model(user).UpdateColumns(updates).Error; err != nil {
+			log.Error("--> NotificationController: UpdateNotificationSettings: Failed to update user: ", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update notification settings",
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Notification settings updated successfully",
+		"data": fiber.Map{
+			"email":         user.Email,
+			"email_opt_out": user.EmailOptOut,
+			"language":      mailer.Language(user.Language),
+		},
+	})
+}
+
+// findUnsubscribeUser loads the user of the token query param of an
+// unsubscribe link. It writes the error response and returns false otherwise.
+func findUnsubscribeUser(c *fiber.Ctx) (models.User, bool) {
+	var user models.User
+	userID, ok := util.VerifyUnsubscribeToken(c.Query("token"))
+	if !ok {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid unsubscribe link",
+		})
+		return user, false
+	}
+	if err := database.DB.Select("id", "language").First(&user, userID).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+		return user, false
+	}
+	return user, true
+}
+
+// UnsubscribePage shows the page of the unsubscribe link of an email, which
+// asks before unsubscribing
+func UnsubscribePage(c *fiber.Ctx) error {
+	user, ok := findUnsubscribeUser(c)
+	if !ok {
+		return nil
+	}
+	text := unsubscribeTexts[mailer.Language(user.Language)]
+	text.Done = ""
+	c.Type("html", "utf-8")
+	return unsubscribePage.Execute(c, text)
+}
+
+// Unsubscribe turns off notification emails for the user of the token query
+// param. Mail clients POST List-Unsubscribe=One-Click to it (RFC 8058) and
+// get JSON; the form of UnsubscribePage gets a confirmation page.
+func Unsubscribe(c *fiber.Ctx) error {
+	user, ok := findUnsubscribeUser(c)
+	if !ok {
+		return nil
+	}
+	if err := database.DB.Model(&user).UpdateColumn("email_opt_out", true).Error; err != nil {
+		log.Error("--> NotificationController: Unsubscribe: Failed to update user: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unsubscribe",
+		})
+	}
+
+	if c.FormValue("List-Unsubscribe") == "One-Click" {
+		return c.JSON(fiber.Map{
+			"message": "Unsubscribed from notification emails",
+		})
+	}
+	c.Type("html", "utf-8")
+	return unsubscribePage.Execute(c, unsubscribeTexts[mailer.Language(user.Language)])
+}
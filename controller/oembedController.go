@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const (
+	oembedDefaultWidth  = 600
+	oembedDefaultHeight = 240
+	oembedCacheAge      = 3600
+)
+
+var oembedCardTemplate = template.Must(template.New("oembed-card").Parse(
+	`<blockquote class="ourjourney-embed" style="box-sizing:border-box;width:{{.Width}}px;max-width:100%;height:{{.Height}}px;margin:0;padding:16px;overflow:hidden;border:1px solid #e5e7eb;border-radius:8px;background:#f3f4f6;font-family:sans-serif">` +
+		`<a href="{{.URL}}" target="_blank" rel="noopener" style="color:#1f2937;text-decoration:none"><strong style="display:block;font-size:18px;margin-bottom:8px">{{.Title}}</strong></a>` +
+		`<p style="margin:0 0 8px;color:#4b5563;font-size:14px">{{.Summary}}</p>` +
+		`<footer style="color:#9ca3af;font-size:12px">{{.Author}} · <a href="{{.SiteURL}}" target="_blank" rel="noopener" style="color:#9ca3af">{{.SiteName}}</a></footer>` +
+		`</blockquote>`))
+
+// oembedCard holds the values rendered into oembedCardTemplate
+type oembedCard struct {
+	Width    int
+	Height   int
+	URL      string
+	Title    string
+	Summary  string
+	Author   string
+	SiteURL  string
+	SiteName string
+}
+
+// postKeyFromURL extracts the post ID or slug from one of our post URLs
+func postKeyFromURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	site, err := url.Parse(siteBaseURL())
+	if err != nil || !strings.EqualFold(u.Host, site.Host) {
+		return "", false
+	}
+
+	path := strings.Trim(u.Path, "/")
+	for _, prefix := range []string{"post/", "api/v1/meta/post/"} {
+		if key := strings.TrimPrefix(path, prefix); key != path && key != "" && !strings.Contains(key, "/") {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// oembedDimension returns fallback capped by an optional maxwidth/maxheight
+// query value
+func oembedDimension(limit string, fallback int) int {
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 || n >= fallback {
+		return fallback
+	}
+	return n
+}
+
+// fitsWithin reports whether size respects an optional maxwidth/maxheight value
+func fitsWithin(limit string, size int) bool {
+	if limit == "" {
+		return true
+	}
+	n, err := strconv.Atoi(limit)
+	return err != nil || n >= size
+}
+
+// OEmbed is an oEmbed provider endpoint returning a rich embed for a post URL
+func OEmbed(c *fiber.Ctx) error {
+	if format := c.Query("format", "json"); format != "json" {
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
+			"message": "Only json format is supported",
+		})
+	}
+
+	key, ok := postKeyFromURL(c.Query("url"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "URL is not a post URL",
+		})
+	}
+
+	post, err := findPublicPost(key)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+
+	maxWidth := c.Query("maxwidth")
+	maxHeight := c.Query("maxheight")
+	width := oembedDimension(maxWidth, oembedDefaultWidth)
+	height := oembedDimension(maxHeight, oembedDefaultHeight)
+
+	baseURL := siteBaseURL()
+	author := strings.TrimSpace(post.User.FirstName + " " + post.User.LastName)
+	card := oembedCard{
+		Width:    width,
+		Height:   height,
+		URL:      fmt.Sprintf("%s/post/%d", baseURL, post.ID),
+		Title:    post.Title,
+		Summary:  postSummary(post, max(width/3, 10)),
+		Author:   author,
+		SiteURL:  baseURL,
+		SiteName: ogSiteName,
+	}
+
+	var sb strings.Builder
+	if err := oembedCardTemplate.Execute(&sb, card); err != nil {
+		log.Error("--> OEmbedController: OEmbed: Failed to render card: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error generating embed",
+		})
+	}
+
+	response := fiber.Map{
+		"version":       "1.0",
+		"type":          "rich",
+		"title":         post.Title,
+		"author_name":   author,
+		"provider_name": ogSiteName,
+		"provider_url":  baseURL,
+		"cache_age":     oembedCacheAge,
+		"html":          sb.String(),
+		"width":         width,
+		"height":        height,
+	}
+
+	// The thumbnail must fit inside maxwidth/maxheight, so it is left out when
+	// the consumer asked for something smaller than the OG image
+	if fitsWithin(maxWidth, ogImageWidth) && fitsWithin(maxHeight, ogImageHeight) {
+		response["thumbnail_url"] = fmt.Sprintf("%s/api/v1/post/%d/og-image", baseURL, post.ID)
+		response["thumbnail_width"] = ogImageWidth
+		response["thumbnail_height"] = ogImageHeight
+	}
+
+	return c.JSON(response)
+}
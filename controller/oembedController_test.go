@@ -0,0 +1,65 @@
+package controller
+
+import "testing"
+
+func TestPostKeyFromURL(t *testing.T) {
+	t.Setenv("BASE_URL", "https://Blog.example.com/")
+	tests := []struct {
+		url  string
+		want string
+		ok   bool
+	}{
+		{"https://blog.example.com/post/12", "12", true},
+		{"https://blog.example.com/post/hello-world/", "hello-world", true},
+		{"http://BLOG.example.com/api/v1/meta/post/7?x=1", "7", true},
+		{"https://blog.example.com/post/", "", false},
+		{"https://blog.example.com/post/1/comments", "", false},
+		{"https://blog.example.com/about", "", false},
+		{"https://other.example.com/post/12", "", false},
+		{"/post/12", "", false},
+		{"::not a url", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := postKeyFromURL(tt.url)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("postKeyFromURL(%q) = %q, %t, want %q, %t", tt.url, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestOEmbedDimension(t *testing.T) {
+	tests := []struct {
+		limit string
+		want  int
+	}{
+		{"", 600},
+		{"abc", 600},
+		{"0", 600},
+		{"-5", 600},
+		{"800", 600},
+		{"600", 600},
+		{"400", 400},
+	}
+	for _, tt := range tests {
+		if got := oembedDimension(tt.limit, 600); got != tt.want {
+			t.Errorf("oembedDimension(%q, 600) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestFitsWithin(t *testing.T) {
+	tests := []struct {
+		limit string
+		want  bool
+	}{
+		{"", true},
+		{"abc", true},
+		{"1200", true},
+		{"1199", false},
+	}
+	for _, tt := range tests {
+		if got := fitsWithin(tt.limit, 1200); got != tt.want {
+			t.Errorf("fitsWithin(%q, 1200) = %t, want %t", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/fogleman/gg"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/gofont/goregular"
+	"gorm.io/gorm"
+)
+
+const (
+	ogImageWidth    = 1200
+	ogImageHeight   = 630
+	ogImageCacheTTL = 1 * time.Hour
+	ogImageCacheMax = 200 // 메모리에 보관하는 최대 이미지 수
+	ogSiteName      = "Our Journey"
+)
+
+// ogCard is the content drawn on an Open Graph image
+type ogCard struct {
+	Label   string // small heading above the title, e.g. "Category"
+	Title   string
+	Summary string
+	Footer  string
+}
+
+type ogCacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// ogImageCache keeps rendered images in memory. Keys include a version
+// (e.g. the last update time) so that edited content gets a fresh image.
+var ogImageCache = struct {
+	sync.RWMutex
+	entries map[string]ogCacheEntry
+}{entries: make(map[string]ogCacheEntry)}
+
+var (
+	ogFont     *truetype.Font
+	ogFontErr  error
+	ogFontOnce sync.Once
+)
+
+func loadOGFont() (*truetype.Font, error) {
+	ogFontOnce.Do(func() {
+		ogFont, ogFontErr = truetype.Parse(goregular.TTF)
+	})
+	return ogFont, ogFontErr
+}
+
+// renderOGImage draws the card on the shared template and returns PNG bytes
+func renderOGImage(card ogCard) ([]byte, error) {
+	font, err := loadOGFont()
+	if err != nil {
+		return nil, err
+	}
+
+	dc := gg.NewContext(ogImageWidth, ogImageHeight)
+	dc.SetHexColor("#f3f4f6")
+	dc.Clear()
+
+	textWidth := float64(ogImageWidth - 100)
+
+	if card.Label != "" {
+		dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: 26}))
+		dc.SetHexColor("#6b7280")
+		dc.DrawString(strings.ToUpper(card.Label), 50, 70)
+	}
+
+	dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: 40}))
+	dc.SetHexColor("#1f2937")
+	dc.DrawStringWrapped(card.Title, 50, 100, 0, 0, textWidth, 1.5, gg.AlignLeft)
+
+	dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: 30}))
+	dc.SetHexColor("#4b5563")
+	dc.DrawStringWrapped(card.Summary, 50, 200, 0, 0, textWidth, 1.5, gg.AlignLeft)
+
+	if card.Footer != "" {
+		dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: 24}))
+		dc.SetHexColor("#9ca3af")
+		dc.DrawString(card.Footer, 50, float64(ogImageHeight-50))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dc.Image()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sendOGImage serves the image for key from the cache, rendering it with
+// build on a miss
+func sendOGImage(c *fiber.Ctx, key string, build func() ogCard) error {
+	ogImageCache.RLock()
+	entry, ok := ogImageCache.entries[key]
+	ogImageCache.RUnlock()
+
+	if !ok || time.Now().After(entry.expires) {
+		data, err := renderOGImage(build())
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Error generating image",
+			})
+		}
+		entry = ogCacheEntry{data: data, expires: time.Now().Add(ogImageCacheTTL)}
+
+		ogImageCache.Lock()
+		for k, e := range ogImageCache.entries {
+			if time.Now().After(e.expires) {
+				delete(ogImageCache.entries, k)
+			}
+		}
+		// 가득 차면 가장 먼저 만료되는 이미지부터 지움
+		for len(ogImageCache.entries) >= ogImageCacheMax {
+			oldest := ""
+			for k, e := range ogImageCache.entries {
+				if oldest == "" || e.expires.Before(ogImageCache.entries[oldest].expires) {
+					oldest = k
+				}
+			}
+			delete(ogImageCache.entries, oldest)
+		}
+		ogImageCache.entries[key] = entry
+		ogImageCache.Unlock()
+	}
+
+	c.Set("Content-Type", "image/png")
+	c.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ogImageCacheTTL.Seconds())))
+	return c.Send(entry.data)
+}
+
+// ogListingCard builds the card for a category or tag listing
+func ogListingCard(label, name string, total int64, posts []models.Post) ogCard {
+	titles := make([]string, 0, len(posts))
+	for _, post := range posts {
+		titles = append(titles, "· "+truncateString(post.Title, 60))
+	}
+	summary := fmt.Sprintf("%d posts", total)
+	if len(titles) > 0 {
+		summary += "\n" + strings.Join(titles, "\n")
+	}
+	return ogCard{
+		Label:   label,
+		Title:   truncateString(name, 80),
+		Summary: summary,
+		Footer:  ogSiteName,
+	}
+}
+
+// GenerateOGImage generates an Open Graph image for a post
+func GenerateOGImage(c *fiber.Ctx) error {
+	postID := c.Params("id")
+	id, err := strconv.Atoi(postID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid post ID",
+		})
+	}
+
+	// 숨겨지거나 휴지통에 있는 포스트는 메타 페이지처럼 찾지 않음
+	post, err := findPublicPost(strconv.Itoa(id))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+
+	version := post.CreatedAt
+	if post.UpdatedAt != nil {
+		version = *post.UpdatedAt
+	}
+	key := fmt.Sprintf("post:%d:%d", post.ID, version.Unix())
+	return sendOGImage(c, key, func() ogCard {
+		return ogCard{
+			Title:   post.Title,
+			Summary: postSummary(post, 200),
+		}
+	})
+}
+
+// GenerateCategoryOGImage generates an Open Graph image for a category listing
+func GenerateCategoryOGImage(c *fiber.Ctx) error {
+	category, _ := url.PathUnescape(c.Params("name"))
+	if category == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Category is required",
+		})
+	}
+
+	query := database.DB.Model(&models.Post{}).
+		Where("category = ? AND (hidden = ? OR hidden IS NULL)", category, false).
+		Session(&gorm.Session{})
+	return sendListingOGImage(c, "Category", category, query)
+}
+
+// GenerateTagOGImage generates an Open Graph image for a tag listing
+func GenerateTagOGImage(c *fiber.Ctx) error {
+	tag, _ := url.PathUnescape(c.Params("name"))
+	if tag == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Tag is required",
+		})
+	}
+
+	query := database.DB.Model(&models.Post{}).
+		Where("FIND_IN_SET(?, tags) > 0 AND (hidden = ? OR hidden IS NULL)", tag, false).
+		Session(&gorm.Session{})
+	return sendListingOGImage(c, "Tag", "#"+tag, query)
+}
+
+// sendListingOGImage renders a listing card for the posts matched by query.
+// query must be a new session so it can be reused for the count and the list.
+func sendListingOGImage(c *fiber.Ctx, label, name string, query *gorm.DB) error {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching posts",
+		})
+	}
+	if total == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "No posts found",
+		})
+	}
+
+	var posts []models.Post
+	query.Order("created_at DESC").Limit(3).Find(&posts)
+
+	key := fmt.Sprintf("%s:%s:%d:%d", strings.ToLower(label), name, total, posts[0].ID)
+	return sendOGImage(c, key, func() ogCard {
+		return ogListingCard(label, name, total, posts)
+	})
+}
+
+// GenerateAuthorOGImage generates an Open Graph image for an author profile
+func GenerateAuthorOGImage(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid user ID",
+		})
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "User not found",
+		})
+	}
+
+	var total int64
+	var latest models.Post
+	postQuery := database.DB.Model(&models.Post{}).
+		Where("user_id = ? AND (hidden = ? OR hidden IS NULL)", user.ID, false).
+		Session(&gorm.Session{})
+	postQuery.Count(&total)
+	postQuery.Order("created_at DESC").Limit(1).Find(&latest)
+
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	key := fmt.Sprintf("author:%d:%s:%d:%d", user.ID, name, total, latest.ID)
+	return sendOGImage(c, key, func() ogCard {
+		summary := fmt.Sprintf("%d posts", total)
+		if latest.ID != 0 {
+			summary += "\nLatest: " + truncateString(latest.Title, 80)
+		}
+		return ogCard{
+			Label:   "Author",
+			Title:   name,
+			Summary: summary,
+			Footer:  ogSiteName,
+		}
+	})
+}
+
+// GenerateAboutOGImage generates an Open Graph image for an about me page
+func GenerateAboutOGImage(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid about information ID",
+		})
+	}
+
+	var aboutInfo models.AboutInfo
+	if err := database.DB.First(&aboutInfo, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "About information not found",
+		})
+	}
+
+	key := fmt.Sprintf("about:%d:%d", aboutInfo.ID, aboutInfo.UpdatedAt.Unix())
+	return sendOGImage(c, key, func() ogCard {
+		return ogCard{
+			Label:   aboutInfo.Title,
+			Title:   aboutInfo.Name,
+			Summary: truncateString(cleanHTMLContent(aboutInfo.Description), 200),
+			Footer:  ogSiteName,
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"io"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestOGListingCard(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int64
+		posts []models.Post
+		want  ogCard
+	}{
+		{"empty", 0, nil, ogCard{Label: "Tag", Title: "go", Summary: "0 posts", Footer: ogSiteName}},
+		{"with posts", 12, []models.Post{{Title: "First"}, {Title: "Second"}},
+			ogCard{Label: "Tag", Title: "go", Summary: "12 posts\n· First\n· Second", Footer: ogSiteName}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ogListingCard("Tag", "go", tt.total, tt.posts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ogListingCard = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// resetOGImageCache empties the cache for a test
+func resetOGImageCache(t *testing.T) {
+	ogImageCache.Lock()
+	ogImageCache.entries = make(map[string]ogCacheEntry)
+	ogImageCache.Unlock()
+	t.Cleanup(func() {
+		ogImageCache.Lock()
+		ogImageCache.entries = make(map[string]ogCacheEntry)
+		ogImageCache.Unlock()
+	})
+}
+
+func TestSendOGImageCache(t *testing.T) {
+	resetOGImageCache(t)
+	builds := 0
+	app := fiber.New()
+	app.Get("/:key", func(c *fiber.Ctx) error {
+		return sendOGImage(c, c.Params("key"), func() ogCard {
+			builds++
+			return ogCard{Title: c.Params("key")}
+		})
+	})
+
+	get := func(key string) []byte {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", "/"+key, nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != fiber.StatusOK || resp.Header.Get("Content-Type") != "image/png" {
+			t.Fatalf("GET /%s = %d %s", key, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+		data, _ := io.ReadAll(resp.Body)
+		return data
+	}
+
+	data := get("post-1")
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("response is not a PNG: %v", err)
+	}
+	if again := get("post-1"); !bytes.Equal(again, data) || builds != 1 {
+		t.Errorf("second request built the image again (%d builds)", builds)
+	}
+
+	// 만료된 이미지는 다시 그림
+	ogImageCache.Lock()
+	entry := ogImageCache.entries["post-1"]
+	entry.expires = time.Now().Add(-time.Second)
+	ogImageCache.entries["post-1"] = entry
+	ogImageCache.Unlock()
+	get("post-1")
+	if builds != 2 {
+		t.Errorf("expired image was not rebuilt (%d builds)", builds)
+	}
+}
+
+func TestSendOGImageCacheLimit(t *testing.T) {
+	resetOGImageCache(t)
+	now := time.Now()
+	ogImageCache.Lock()
+	for i := 0; i < ogImageCacheMax; i++ {
+		ogImageCache.entries[fmt.Sprint("full-", i)] = ogCacheEntry{expires: now.Add(time.Hour + time.Duration(i)*time.Second)}
+	}
+	ogImageCache.Unlock()
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		return sendOGImage(c, "new", func() ogCard { return ogCard{Title: "new"} })
+	})
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	ogImageCache.RLock()
+	defer ogImageCache.RUnlock()
+	if n := len(ogImageCache.entries); n != ogImageCacheMax {
+		t.Errorf("cache holds %d images, want %d", n, ogImageCacheMax)
+	}
+	if _, ok := ogImageCache.entries["full-0"]; ok {
+		t.Error("the image that expires first was not evicted")
+	}
+	if _, ok := ogImageCache.entries["new"]; !ok {
+		t.Error("the new image was not cached")
+	}
+}
@@ -16,17 +16,12 @@ import (
 	"html"
 	"regexp"
 
-	"image/png"
-
 	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
 	"github.com/bloomingFlower/blog-backend/models"
 	"github.com/bloomingFlower/blog-backend/util"
-	"github.com/fogleman/gg"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
-	"github.com/golang/freetype/truetype"
-	"github.com/gorilla/feeds"
-	"golang.org/x/image/font/gofont/goregular"
 )
 
 func CreatePost(c *fiber.Ctx) error {
@@ -61,6 +56,7 @@ func CreatePost(c *fiber.Ctx) error {
 	}
 
 	category := c.FormValue("category")
+	lang := normalizeLang(c.FormValue("lang"))
 
 	// 데이터베이스에 저장
 	blogpost := models.Post{
@@ -69,14 +65,29 @@ func CreatePost(c *fiber.Ctx) error {
 		Content:   content,
 		Tags:      strings.Join(tags, ","),
 		Category:  category,
+		Lang:      lang,
 		UpdatedAt: nil,
 	}
+	// 비밀번호를 아는 사람만 읽을 수 있는 포스트
+	blogpost.SetPassword(c.FormValue("password"))
 	if err := database.DB.Create(&blogpost).Error; err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": "Unable to create post",
 		})
 	}
 
+	// 포스트 ID가 정해진 후 slug 생성
+	slug := c.FormValue("slug")
+	if slug == "" {
+		slug = title
+	}
+	blogpost.Slug = util.UniquePostSlug(database.DB, slug, blogpost.ID)
+	if err := database.DB.Model(&blogpost).UpdateColumn("slug", blogpost.Slug).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to save post slug",
+		})
+	}
+
 	// 포스트 ID를 기준으로 디렉토리 생성
 	dirPath := fmt.Sprintf("uploads/%d", blogpost.ID)
 	_, err = os.Stat(dirPath)
@@ -87,22 +98,9 @@ func CreatePost(c *fiber.Ctx) error {
 		}
 	}
 
-	// 파일이 있을 경우, 파일 저장
-	filePath := ""
-	if _, err := c.FormFile("file"); err == nil {
-		filePath, err = SaveFile(c, dirPath)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Could not save file",
-			})
-		}
-		blogpost.File = fmt.Sprintf("files/%d/%s", blogpost.ID, filePath)
-		log.Debug("--> PostController: CreatePost: blogpost.File: ", blogpost.File)
-		if err = database.DB.Save(&blogpost).Error; err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"message": "Unable to save file path",
-			})
-		}
+	// 파일이 있을 경우 첨부파일로 저장 (file 파트 여러 개 허용)
+	if _, err := saveAttachments(c, &blogpost); err != nil {
+		return attachmentError(c, err)
 	}
 
 	return c.JSON(fiber.Map{
@@ -110,6 +108,126 @@ func CreatePost(c *fiber.Ctx) error {
 	})
 }
 
+// postIDParam parses the :id param. It writes the error response and returns
+// false when the id is not a number.
+func postIDParam(c *fiber.Ctx) (uint, bool) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "postID must be a valid integer",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// visiblePosts restricts query to the posts the user may see. Hidden posts
+// are shown only to their authors and to users who may view hidden posts.
+func visiblePosts(query *gorm.DB, user *models.User) *gorm.DB {
+	if user.Can(models.PermViewHiddenPosts) {
+		return query
+	}
+	if user != nil {
+		return query.Where("(posts.hidden = ? OR posts.hidden IS NULL OR posts.user_id = ? OR posts.id IN (?))", false, user.ID,
+			database.DB.Model(&models.PostCollaborator{}).Select("post_id").Where("user_id = ? AND accepted_at IS NOT NULL", user.ID))
+	}
+	return query.Where("(posts.hidden = ? OR posts.hidden IS NULL)", false)
+}
+
+// canManagePost reports whether the user may delete the post and manage its
+// collaborators
+func canManagePost(user *models.User, post models.Post) bool {
+	if user == nil {
+		return false
+	}
+	return post.UserID == user.ID || user.Can(models.PermEditAnyPost)
+}
+
+// canEditPost reports whether the user may update the post
+func canEditPost(user *models.User, post models.Post) bool {
+	if canManagePost(user, post) {
+		return true
+	}
+	collaborator := postCollaborator(post.ID, user)
+	return collaborator != nil && collaborator.CanEdit
+}
+
+// canPublishPost reports whether the user may hide or unhide the post
+func canPublishPost(user *models.User, post models.Post) bool {
+	if canManagePost(user, post) {
+		return true
+	}
+	collaborator := postCollaborator(post.ID, user)
+	return collaborator != nil && collaborator.CanPublish
+}
+
+// postETag returns the ETag of the current version of the post
+func postETag(post models.Post) string {
+	return fmt.Sprintf(`"%d-%d"`, post.ID, post.Version)
+}
+
+// requestedPostVersion returns the version the client based its change on,
+// from the If-Match header, the version field or the version query parameter.
+// fromHeader tells which one was used, since a stale If-Match is answered
+// with 412 and a stale version with 409.
+func requestedPostVersion(c *fiber.Ctx, post models.Post) (version uint, fromHeader bool, ok bool) {
+	if ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch)); ifMatch != "" {
+		if ifMatch == "*" {
+			return post.Version, true, true
+		}
+		for _, tag := range strings.Split(ifMatch, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == postETag(post) {
+				return post.Version, true, true
+			}
+		}
+		// 일치하는 ETag가 없으면 오래된 버전으로 처리
+		return 0, true, true
+	}
+	// DELETE 요청은 본문이 없을 수 있어 쿼리 파라미터도 허용
+	for _, value := range []string{c.FormValue("version"), c.Query("version")} {
+		if v, err := strconv.ParseUint(value, 10, 32); err == nil {
+			return uint(v), false, true
+		}
+	}
+	return 0, false, false
+}
+
+// checkPostVersion rejects the request unless it carries the current version
+// of the post. It writes the error response and returns false otherwise.
+func checkPostVersion(c *fiber.Ctx, post models.Post) (version uint, fromHeader bool, ok bool) {
+	version, fromHeader, ok = requestedPostVersion(c, post)
+	if !ok {
+		c.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{
+			"message":         "If-Match header or version is required",
+			"current_version": post.Version,
+		})
+		return 0, false, false
+	}
+	if version != post.Version {
+		postVersionConflict(c, post.ID, fromHeader)
+		return 0, false, false
+	}
+	return version, fromHeader, true
+}
+
+// postVersionConflict answers a write based on a stale version with the
+// current state of the post so the editor can offer to merge
+func postVersionConflict(c *fiber.Ctx, postID uint, fromHeader bool) error {
+	var current models.Post
+	database.DB.Preload("User").First(&current, postID)
+	status := fiber.StatusConflict
+	if fromHeader {
+		status = fiber.StatusPreconditionFailed
+	}
+	c.Set(fiber.HeaderETag, postETag(current))
+	return c.Status(status).JSON(fiber.Map{
+		"message":         "The post was changed by someone else",
+		"current_version": current.Version,
+		"data":            current,
+	})
+}
+
 // AllPost returns all posts
 func AllPost(c *fiber.Ctx) error {
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -119,23 +237,11 @@ func AllPost(c *fiber.Ctx) error {
 	var posts []models.Post
 
 	category := c.Query("category", "")
-
-	// Check if the user is logged in
-	cookie := c.Cookies("jwt")
-	idStr, err := util.ParseJwt(cookie)
-	if err != nil {
-		idStr = "0"
-	}
-	idInt, err := strconv.Atoi(idStr)
-	if err != nil {
-		log.Error(err.Error())
-		return err
-	}
-	userId := uint(idInt)
+	user := middleware.CurrentUser(c)
 
 	// Generate the base query
 	query := database.DB.Table("posts").
-		Select("posts.*, COUNT(DISTINCT CASE WHEN comments.deleted_at IS NULL THEN comments.id END) as comment_count").
+		Select("posts.*, COUNT(DISTINCT CASE WHEN comments.deleted_at IS NULL AND comments.status = ? THEN comments.id END) as comment_count", models.CommentApproved).
 		Joins("LEFT JOIN comments ON comments.post_id = posts.id").
 		Group("posts.id").
 		Preload("User").
@@ -146,10 +252,7 @@ func AllPost(c *fiber.Ctx) error {
 		query = query.Where("category = ?", category)
 	}
 
-	if userId == 0 {
-		// If not logged in, exclude hidden posts
-		query = query.Where("hidden = ? OR hidden IS NULL", false)
-	}
+	query = visiblePosts(query, user)
 
 	// Apply pagination and retrieve results
 	var results []struct {
@@ -168,15 +271,21 @@ func AllPost(c *fiber.Ctx) error {
 		posts[i] = result.Post
 		posts[i].CommentCount = result.CommentCount
 	}
+	loadPostAuthors(posts)
+	applyTranslations(posts, requestedLanguages(c))
+	for i := range posts {
+		if !canReadPost(c, posts[i]) {
+			lockPost(&posts[i])
+		}
+	}
+	c.Vary(fiber.HeaderAcceptLanguage)
 
 	// Count the total number of posts
 	countQuery := database.DB.Model(&models.Post{})
 	if category != "" {
 		countQuery = countQuery.Where("category = ?", category)
 	}
-	if userId == 0 {
-		countQuery = countQuery.Where("hidden = ? OR hidden IS NULL", false)
-	}
+	countQuery = visiblePosts(countQuery, user)
 	countQuery.Count(&total)
 
 	lastPage := int(math.Ceil(float64(total) / float64(limit)))
@@ -194,46 +303,44 @@ func DetailPost(c *fiber.Ctx) error {
 	// Extract the post ID from the request
 	postID := c.Params("id")
 	// Check if postID is a valid integer
-	if _, err := strconv.Atoi(postID); err != nil {
+	id, err := strconv.Atoi(postID)
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": "postID must be a valid integer",
 		})
 	}
 
-	// Find the post with the given ID
+	// Find the post with the given ID, excluding hidden posts the user may not
+	// see unless the request has a share link
 	var post models.Post
-	// Check if the user is logged in
-	cookie := c.Cookies("jwt")
-	idStr, err := util.ParseJwt(cookie)
-	if err != nil {
-		idStr = "0"
+	query := viewablePosts(c, uint(id), false)
+	result := query.Preload("User").Preload("Attachments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Where("id = ?", postID).First(&post)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
 	}
-	idInt, err := strconv.Atoi(idStr)
-	if err != nil {
-		log.Error(err.Error())
-		return err
-	}
-	userId := uint(idInt)
-	if userId == 0 {
-		// If not logged in, exclude hidden posts
-		result := database.DB.Preload("User").Where("id = ? AND (hidden = ? OR hidden IS NULL)", postID, false).First(&post)
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"message": "Post not found",
-			})
-		}
-	} else {
-		result := database.DB.Preload("User").Where("id = ?", postID).First(&post)
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"message": "Post not found",
-			})
-		}
+
+	// 공동 작성자와 번역 언어 목록 포함
+	posts := []models.Post{post}
+	loadPostAuthors(posts)
+	loadPostLanguages(&posts[0])
+	applyTranslations(posts, requestedLanguages(c))
+	if !canReadPost(c, posts[0]) {
+		lockPost(&posts[0])
+	}
+	// 작성자 본인의 조회는 집계하지 않음
+	if !canEditPost(middleware.CurrentUser(c), post) {
+		recordPostView(post.ID)
 	}
 
 	// Return the post details
+	c.Set(fiber.HeaderETag, postETag(post))
+	c.Vary(fiber.HeaderAcceptLanguage)
 	return c.JSON(fiber.Map{
-		"data": post,
+		"data": posts[0],
 	})
 }
 
@@ -248,21 +355,6 @@ func UpdatePost(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if the user is logged in
-	cookie := c.Cookies("jwt")
-	idStr, err := util.ParseJwt(cookie)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"message": "Unauthorized",
-		})
-	}
-	userID, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid user ID",
-		})
-	}
-
 	// Find the post
 	var post models.Post
 	if err := database.DB.First(&post, postID).Error; err != nil {
@@ -271,13 +363,20 @@ func UpdatePost(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if the user is the owner of the post
-	if post.UserID != uint(userID) {
+	// Check if the user is an author of the post or may edit any post
+	user := middleware.CurrentUser(c)
+	if !canEditPost(user, post) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"message": "You don't have permission to update this post",
 		})
 	}
 
+	// 다른 탭이나 공동 작성자의 변경을 덮어쓰지 않도록 버전 확인
+	version, fromHeader, ok := checkPostVersion(c, post)
+	if !ok {
+		return nil
+	}
+
 	// 제목, Quill의 내용, 해시태그 파싱
 	title := c.FormValue("title")
 	content := c.FormValue("content")
@@ -285,12 +384,6 @@ func UpdatePost(c *fiber.Ctx) error {
 	tags := []string{}
 	if tagsJSON != "" {
 		tags = strings.Split(tagsJSON, ",") // ["fdg", "hgfj", "dsfg", "gfhj"]	err = json.Unmarshal([]byte(tagsJSON), &tags)
-		if err != nil {
-			log.Error("Error parsing tags:", err)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"message": "Error parsing tags",
-			})
-		}
 	}
 
 	category := c.FormValue("category")
@@ -298,7 +391,7 @@ func UpdatePost(c *fiber.Ctx) error {
 	// 현재 시간을 UpdatedAt으로 설정
 	now := time.Now()
 	blogpost := models.Post{
-		UserID:    uint(userID),
+		UserID:    post.UserID,
 		Title:     title,
 		Content:   content,
 		Tags:      strings.Join(tags, ","),
@@ -308,16 +401,52 @@ func UpdatePost(c *fiber.Ctx) error {
 	if err := c.BodyParser(&blogpost); err != nil {
 		fmt.Println("Error parsing body")
 	}
-	result := database.DB.Model(&blogpost).Where("id = ?", postID).Updates(blogpost)
+	// 작성자는 변경할 수 없고, 공개 여부는 게시 권한이 있어야 변경 가능
+	blogpost.UserID = post.UserID
+	blogpost.Lang = normalizeLang(c.FormValue("lang"))
+	// 비밀번호는 아래에서 따로 변경
+	blogpost.Protected = false
+	blogpost.Password = nil
+	if !canPublishPost(user, post) {
+		blogpost.Hidden = post.Hidden
+	}
+	// JSON 본문으로 받은 slug도 정리하고 다른 포스트와 겹치지 않게 함
+	slug := c.FormValue("slug")
+	if slug == "" {
+		slug = blogpost.Slug
+	}
+	blogpost.Slug = ""
+	if slug != "" {
+		blogpost.Slug = util.UniquePostSlug(database.DB, slug, post.ID)
+	}
+	blogpost.Version = version + 1
+	result := database.DB.Model(&blogpost).Where("id = ? AND version = ?", postID, version).Updates(blogpost)
 	if result.Error != nil {
 		log.Error("Error updating post:", result.Error)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Error updating post",
 		})
 	}
+	// 확인 이후 다른 요청이 먼저 저장한 경우
+	if result.RowsAffected == 0 {
+		return postVersionConflict(c, post.ID, fromHeader)
+	}
+	post.Version = blogpost.Version
+
+	// 비밀번호 설정 또는 해제, 공개 여부처럼 게시 권한 필요
+	password := c.FormValue("password")
+	if (password != "" || c.FormValue("remove_password") == "true") && canPublishPost(user, post) {
+		post.SetPassword(password)
+		if err := database.DB.Model(&post).Select("Protected", "Password").Updates(&post).Error; err != nil {
+			log.Error("Error updating post password:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": "Error updating post",
+			})
+		}
+	}
 	// 포스트 ID를 기준으로 디렉토리 생성
 	dirPath := fmt.Sprintf("uploads/%d", post.ID)
-	_, err = os.Stat(dirPath)
+	_, err := os.Stat(dirPath)
 	if os.IsNotExist(err) {
 		errDir := os.MkdirAll(dirPath, 0755)
 		if errDir != nil {
@@ -325,45 +454,62 @@ func UpdatePost(c *fiber.Ctx) error {
 		}
 	}
 
-	// 파일이 있을 경우, 파일 저장
-	filename := ""
-	if _, err := c.FormFile("file"); err == nil {
-		// 기존 파일 삭제
-		if post.File != "" {
-			log.Debug("--> PostController: UpdatePost: post.ID: ", post.ID)
-			log.Debug("--> PostController: UpdatePost: post.File: ", post.File)
-			oldFilePath := filepath.Join("uploads", strconv.Itoa(int(post.ID)), filepath.Base(post.File))
-			if err := os.Remove(oldFilePath); err != nil {
-				log.Error("Error deleting old file: %v", err)
-			}
-		}
-
-		// 새 파일 저장
-		filename, err = SaveFile(c, dirPath)
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"message": "Could not save file",
-			})
-		}
-		log.Debug("--> PostController: UpdatePost: post.ID: ", post.ID)
-		post.File = fmt.Sprintf("files/%d/%s", post.ID, filename)
-		log.Debug("--> PostController: UpdatePost: post.File: ", post.File)
-		if err = database.DB.Model(&post).Update("file", post.File).Error; err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"message": "Unable to update file path",
-			})
-		}
+	// 새 파일은 기존 첨부파일 뒤에 추가
+	if _, err := saveAttachments(c, &post); err != nil {
+		return attachmentError(c, err)
 	}
 
+	c.Set(fiber.HeaderETag, postETag(post))
 	return c.JSON(fiber.Map{
 		"message": "Post updated successfully",
+		"version": post.Version,
 	})
 }
 
-func ServeFile(c *fiber.Ctx) error {
-	id := c.Params("id")
+// uploadPath returns the path of the :filename file of the :id post if the
+// request may read the post. Files of trashed, hidden and locked posts are
+// withheld like the posts. It writes the error response and returns false
+// otherwise.
+func uploadPath(c *fiber.Ctx) (string, bool) {
 	filename := c.Params("filename")
-	path := fmt.Sprintf("uploads/%s/%s", id, filename)
+	if filename != filepath.Base(filename) || filename == "." || filename == ".." {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid filename",
+		})
+		return "", false
+	}
+	// 포스트가 정해지기 전에 올린 이미지
+	if c.Params("id") == "temp" {
+		return filepath.Join("uploads", "temp", filename), true
+	}
+
+	id, ok := postIDParam(c)
+	if !ok {
+		return "", false
+	}
+	var post models.Post
+	query := viewablePosts(c, id, false).Select("id", "user_id", "protected", "password")
+	if err := query.Where("id = ?", id).First(&post).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+		return "", false
+	}
+	if !canReadPost(c, post) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "Post is protected",
+		})
+		return "", false
+	}
+	return filepath.Join("uploads", fmt.Sprint(id), filename), true
+}
+
+func ServeFile(c *fiber.Ctx) error {
+	path, ok := uploadPath(c)
+	if !ok {
+		return nil
+	}
+	filename := filepath.Base(path)
 	log.Debug("--> PostController: ServeFile: path: ", path)
 
 	// Content-Disposition 헤더 설정
@@ -374,6 +520,21 @@ func ServeFile(c *fiber.Ctx) error {
 	return c.SendFile(path)
 }
 
+// ServeUpload serves an uploaded file inline, such as an image in the content
+// of a post
+func ServeUpload(c *fiber.Ctx) error {
+	path, ok := uploadPath(c)
+	if !ok {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "File not found",
+		})
+	}
+	return c.SendFile(path)
+}
+
 func UniquePost(c *fiber.Ctx) error {
 	cookie := c.Cookies("jwt")
 	id, err := util.ParseJwt(cookie)
@@ -385,29 +546,13 @@ func UniquePost(c *fiber.Ctx) error {
 		})
 	}
 	var posts []models.Post
-	database.DB.Model(&posts).Where("user_id=?", id).Preload("User").Find(&posts)
-	database.DB.Debug().Model(&models.Post{}).Where("user_id=?", id).Preload("User").Find(&posts)
+	database.DB.Where("user_id=?", id).Preload("User").Find(&posts)
 	return c.JSON(posts)
 }
 
 func DeletePost(c *fiber.Ctx) error {
 	id, _ := strconv.Atoi(c.Params("id"))
 
-	// Check if the user is logged in
-	cookie := c.Cookies("jwt")
-	idStr, err := util.ParseJwt(cookie)
-	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"message": "Unauthorized",
-		})
-	}
-	userID, err := strconv.ParseUint(idStr, 10, 32)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid user ID",
-		})
-	}
-
 	// Find the post
 	var post models.Post
 	if err := database.DB.First(&post, id).Error; err != nil {
@@ -416,23 +561,31 @@ func DeletePost(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check if the user is the owner of the post
-	if post.UserID != uint(userID) {
+	// Only the owner of the post or users who may edit any post can delete it
+	if !canManagePost(middleware.CurrentUser(c), post) {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"message": "You don't have permission to delete this post",
 		})
 	}
 
-	// Delete the post
-	deleteQuery := database.DB.Delete(&post)
+	version, fromHeader, ok := checkPostVersion(c, post)
+	if !ok {
+		return nil
+	}
+
+	// Move the post to the trash; it is purged with its files later
+	deleteQuery := database.DB.Where("version = ?", version).Delete(&post)
 	if deleteQuery.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Unable to delete post",
 		})
 	}
+	if deleteQuery.RowsAffected == 0 {
+		return postVersionConflict(c, post.ID, fromHeader)
+	}
 
 	return c.JSON(fiber.Map{
-		"message": "Post deleted successfully",
+		"message": "Post moved to trash",
 	})
 }
 
@@ -450,19 +603,26 @@ func SearchPost(c *fiber.Ctx) error {
 	var total int64
 
 	// Perform the search based on the type
+	like := "%" + query + "%"
+	search := visiblePosts(database.DB.Model(&models.Post{}), middleware.CurrentUser(c))
+	// 보호된 포스트의 내용은 검색하지 않음
 	switch searchType {
 	case "title":
-		database.DB.Where("title LIKE ?", "%"+query+"%").Offset(offset).Limit(limit).Find(&posts)
-		database.DB.Model(&models.Post{}).Where("title LIKE ?", "%"+query+"%").Count(&total)
+		search = search.Where("title LIKE ?", like)
 	case "content":
-		database.DB.Where("content LIKE ?", "%"+query+"%").Offset(offset).Limit(limit).Find(&posts)
-		database.DB.Model(&models.Post{}).Where("content LIKE ?", "%"+query+"%").Count(&total)
+		search = search.Where("protected = ? AND content LIKE ?", false, like)
 	case "tags":
-		database.DB.Where("tags LIKE ?", "%"+query+"%").Offset(offset).Limit(limit).Find(&posts)
-		database.DB.Model(&models.Post{}).Where("tags LIKE ?", "%"+query+"%").Count(&total)
+		search = search.Where("tags LIKE ?", like)
 	default: // Search all fields
-		database.DB.Where("title LIKE ? OR content LIKE ? OR tags LIKE ?", "%"+query+"%", "%"+query+"%", "%"+query+"%").Offset(offset).Limit(limit).Find(&posts)
-		database.DB.Model(&models.Post{}).Where("title LIKE ? OR content LIKE ? OR tags LIKE ?", "%"+query+"%", "%"+query+"%", "%"+query+"%").Count(&total)
+		search = search.Where("title LIKE ? OR (protected = ? AND content LIKE ?) OR tags LIKE ?", like, false, like, like)
+	}
+	search = search.Session(&gorm.Session{})
+	search.Offset(offset).Limit(limit).Find(&posts)
+	search.Count(&total)
+	for i := range posts {
+		if !canReadPost(c, posts[i]) {
+			lockPost(&posts[i])
+		}
 	}
 
 	// Calculate the last page number
@@ -492,6 +652,18 @@ func HidePost(c *fiber.Ctx) error {
 		})
 	}
 
+	// Check if the user is an author of the post allowed to publish it
+	if !canPublishPost(middleware.CurrentUser(c), post) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to hide this post",
+		})
+	}
+
+	version, fromHeader, ok := checkPostVersion(c, post)
+	if !ok {
+		return nil
+	}
+
 	// Hide the post
 	if post.Hidden {
 		post.Hidden = false
@@ -502,80 +674,28 @@ func HidePost(c *fiber.Ctx) error {
 	// Save the current state of the post.Hidden field
 	hidden := post.Hidden
 	// 	result = database.DB.Model(&post).Updates(models.Post{Hidden: hidden}) 이건 zero value 무시
-	result = database.DB.Model(&post).UpdateColumn("hidden", hidden)
+	result = database.DB.Model(&post).Where("version = ?", version).UpdateColumns(map[string]interface{}{
+		"hidden":  hidden,
+		"version": version + 1,
+	})
 	if result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": "Error updating post",
 		})
 	}
+	if result.RowsAffected == 0 {
+		return postVersionConflict(c, post.ID, fromHeader)
+	}
 
 	// Return a success message
+	post.Version = version + 1
+	c.Set(fiber.HeaderETag, postETag(post))
 	return c.JSON(fiber.Map{
 		"message": "Post hidden successfully",
+		"version": post.Version,
 	})
 }
 
-// RSSFeed generates an RSS feed of recent posts
-func RSSFeed(c *fiber.Ctx) error {
-	var posts []models.Post
-	limit := 10 // Get the latest 10 posts
-
-	result := database.DB.Where("hidden = ? OR hidden IS NULL", false).
-		Order("created_at DESC").
-		Limit(limit).
-		Find(&posts)
-
-	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Error fetching posts",
-		})
-	}
-
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		baseURL = "http://localhost:8008"
-	}
-
-	feed := &feeds.Feed{
-		Title:       "Our Journey",
-		Link:        &feeds.Link{Href: baseURL},
-		Description: "Recent posts from Our Journey",
-		Author:      &feeds.Author{Name: "Our Journey"},
-		Created:     time.Now(),
-	}
-
-	for _, post := range posts {
-		cleanContent := cleanHTMLContent(post.Content)
-
-		// Limit the length of the title
-		title := truncateString(post.Title, 100)
-
-		// Limit the length of the description
-		description := truncateString(cleanContent, 300)
-
-		item := &feeds.Item{
-			Title:       title,
-			Link:        &feeds.Link{Href: fmt.Sprintf("%s/post/%d", baseURL, post.ID)},
-			Description: description,
-			Author:      &feeds.Author{Name: post.User.FirstName + " " + post.User.LastName},
-			Created:     post.CreatedAt,
-		}
-		feed.Items = append(feed.Items, item)
-	}
-
-	rss, err := feed.ToRss()
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Error generating RSS feed",
-		})
-	}
-
-	rss = strings.Replace(rss, `<?xml version="1.0"?>`, `<?xml version="1.0" encoding="UTF-8"?>`, 1)
-
-	c.Set("Content-Type", "application/rss+xml; charset=utf-8")
-	return c.Send([]byte(rss))
-}
-
 func cleanHTMLContent(content string) string {
 	// Remove HTML tags
 	re := regexp.MustCompile("<[^>]*>")
@@ -595,50 +715,3 @@ func truncateString(s string, maxLength int) string {
 	truncated := string(runes[:maxLength-3]) + "..."
 	return truncated
 }
-
-// GenerateOGImage generates an Open Graph image for a post
-func GenerateOGImage(c *fiber.Ctx) error {
-	postID := c.Params("id")
-	id, err := strconv.Atoi(postID)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"message": "Invalid post ID",
-		})
-	}
-
-	var post models.Post
-	if err := database.DB.First(&post, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Post not found",
-		})
-	}
-
-	width := 1200
-	height := 630
-
-	dc := gg.NewContext(width, height)
-
-	dc.SetHexColor("#f3f4f6")
-	dc.Clear()
-
-	font, err := truetype.Parse(goregular.TTF)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": "Error loading font",
-		})
-	}
-
-	titleFontFace := truetype.NewFace(font, &truetype.Options{Size: 40})
-	dc.SetFontFace(titleFontFace)
-	dc.SetHexColor("#1f2937")
-	dc.DrawStringWrapped(post.Title, 50, 100, 0, 0, float64(width-100), 1.5, gg.AlignLeft)
-
-	contentFontFace := truetype.NewFace(font, &truetype.Options{Size: 30})
-	dc.SetFontFace(contentFontFace)
-	dc.SetHexColor("#4b5563")
-	summary := truncateString(cleanHTMLContent(post.Content), 200)
-	dc.DrawStringWrapped(summary, 50, 200, 0, 0, float64(width-100), 1.5, gg.AlignLeft)
-
-	c.Set("Content-Type", "image/png")
-	return png.Encode(c.Response().BodyWriter(), dc.Image())
-}
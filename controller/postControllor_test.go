@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestPostIDParam(t *testing.T) {
+	app := fiber.New()
+	app.Get("/post/:id", func(c *fiber.Ctx) error {
+		id, ok := postIDParam(c)
+		if !ok {
+			return nil
+		}
+		return c.JSON(fiber.Map{"id": id})
+	})
+
+	tests := []struct {
+		id     string
+		status int
+		body   string
+	}{
+		{"12", fiber.StatusOK, `{"id":12}`},
+		{"abc", fiber.StatusBadRequest, `{"message":"postID must be a valid integer"}`},
+		{"-1", fiber.StatusBadRequest, `{"message":"postID must be a valid integer"}`},
+		{"1.5", fiber.StatusBadRequest, `{"message":"postID must be a valid integer"}`},
+		{"4294967296", fiber.StatusBadRequest, `{"message":"postID must be a valid integer"}`},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest("GET", "/post/"+tt.id, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || string(body) != tt.body {
+			t.Errorf("GET /post/%s = %d %s, want %d %s", tt.id, resp.StatusCode, body, tt.status, tt.body)
+		}
+	}
+}
+
+func TestRequestedPostVersion(t *testing.T) {
+	post := models.Post{ID: 7, Version: 3}
+	tests := []struct {
+		name       string
+		ifMatch    string
+		query      string
+		body       string
+		version    uint
+		fromHeader bool
+		ok         bool
+	}{
+		{"nothing", "", "", "", 0, false, false},
+		{"current etag", `"7-3"`, "", "", 3, true, true},
+		{"weak etag in a list", `"7-1", W/"7-3"`, "", "", 3, true, true},
+		{"any", "*", "", "", 3, true, true},
+		{"stale etag", `"7-2"`, "", "", 0, true, true},
+		{"etag of another post", `"8-3"`, "", "", 0, true, true},
+		{"header wins over the query", `"7-2"`, "3", "", 0, true, true},
+		{"query", "", "2", "", 2, false, true},
+		{"form body", "", "", "version=3", 3, false, true},
+		{"invalid query", "", "x", "", 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Put("/", func(c *fiber.Ctx) error {
+				version, fromHeader, ok := requestedPostVersion(c, post)
+				if version != tt.version || fromHeader != tt.fromHeader || ok != tt.ok {
+					t.Errorf("requestedPostVersion = %d, %t, %t, want %d, %t, %t",
+						version, fromHeader, ok, tt.version, tt.fromHeader, tt.ok)
+				}
+				return nil
+			})
+			target := "/"
+			if tt.query != "" {
+				target += "?version=" + tt.query
+			}
+			req := httptest.NewRequest("PUT", target, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		})
+	}
+}
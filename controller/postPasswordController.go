@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+const postUnlockTTL = 24 * time.Hour
+
+// protectedSummary replaces the excerpt of password protected posts
+const protectedSummary = "This post is protected by a password."
+
+func postUnlockCookie(postID uint) string {
+	return fmt.Sprintf("post_unlock_%d", postID)
+}
+
+// canReadPost reports whether the request may see the content of the post:
+// it is not protected, the user may edit it, it was shared, or it was unlocked
+func canReadPost(c *fiber.Ctx, post models.Post) bool {
+	if !post.Protected {
+		return true
+	}
+	if canEditPost(middleware.CurrentUser(c), post) || activeShareLink(c, post.ID) != nil {
+		return true
+	}
+	return util.VerifyPostUnlockToken(c.Cookies(postUnlockCookie(post.ID)), post.ID, post.Password)
+}
+
+// lockPost withholds everything but the title and metadata of a protected post
+func lockPost(post *models.Post) {
+	post.Content = ""
+	post.File = ""
+	post.Attachments = nil
+	post.Locked = true
+}
+
+// postSummary returns a plain text excerpt of at most n characters. It never
+// uses the content of protected posts.
+func postSummary(post models.Post, n int) string {
+	if post.Protected {
+		return protectedSummary
+	}
+	return truncateString(cleanHTMLContent(post.Content), n)
+}
+
+// UnlockPost checks the password of a protected post and sets a cookie,
+// named after the post, that lets the visitor read it and its files
+func UnlockPost(c *fiber.Ctx) error {
+	id, ok := postIDParam(c)
+	if !ok {
+		return nil
+	}
+	var post models.Post
+	query := visiblePosts(database.DB.Model(&models.Post{}), middleware.CurrentUser(c))
+	if err := query.Where("id = ?", id).First(&post).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+	if !post.Protected {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Post is not protected",
+		})
+	}
+
+	var data struct {
+		Password string `json:"password" form:"password"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+	if err := post.ComparePassword(data.Password); err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"message": "Incorrect password",
+		})
+	}
+
+	expires := time.Now().Add(postUnlockTTL)
+	c.Cookie(&fiber.Cookie{
+		Name:     postUnlockCookie(post.ID),
+		Value:    util.PostUnlockToken(post.ID, post.Password, expires),
+		Path:     "/api/v1",
+		Expires:  expires,
+		HTTPOnly: true,
+		SameSite: "Lax",
+	})
+
+	return c.JSON(fiber.Map{
+		"message": "Post unlocked successfully",
+		"expires": expires,
+	})
+}
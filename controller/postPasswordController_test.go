@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestCanReadPost(t *testing.T) {
+	protected := models.Post{ID: 5, UserID: 1, Protected: true, Password: []byte("$2a$14$hash")}
+	valid := util.PostUnlockToken(5, protected.Password, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		post   models.Post
+		user   *models.User
+		cookie string
+		want   bool
+	}{
+		{"not protected", models.Post{ID: 5}, nil, "", true},
+		{"locked", protected, nil, "", false},
+		{"unlocked", protected, nil, valid, true},
+		{"cookie for another post", protected, nil, util.PostUnlockToken(6, protected.Password, time.Now().Add(time.Hour)), false},
+		{"password changed", protected, nil, util.PostUnlockToken(5, []byte("$2a$14$old"), time.Now().Add(time.Hour)), false},
+		{"author", protected, &models.User{ID: 1, Role: models.RoleAuthor}, "", true},
+		{"admin", protected, &models.User{ID: 2, Role: models.RoleAdmin}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			var got bool
+			app.Get("/", func(c *fiber.Ctx) error {
+				c.Locals("user", tt.user)
+				got = canReadPost(c, tt.post)
+				return nil
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.cookie != "" {
+				req.Header.Set("Cookie", postUnlockCookie(tt.post.ID)+"="+tt.cookie)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("canReadPost = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockPost(t *testing.T) {
+	post := models.Post{
+		Title:       "Secret",
+		Content:     "<p>hidden</p>",
+		File:        "/api/v1/uploads/a.png",
+		Attachments: []models.PostAttachment{{ID: 1}},
+	}
+	lockPost(&post)
+	if post.Content != "" || post.File != "" || post.Attachments != nil || !post.Locked {
+		t.Errorf("lockPost left %+v", post)
+	}
+	if post.Title != "Secret" {
+		t.Errorf("Title = %q, want it kept", post.Title)
+	}
+}
+
+func TestPostSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		post models.Post
+		n    int
+		want string
+	}{
+		{"plain", models.Post{Content: "<p>Hello &amp; welcome</p>"}, 100, "Hello & welcome"},
+		{"truncated", models.Post{Content: "<p>안녕하세요 여러분</p>"}, 6, "안녕하..."},
+		{"protected", models.Post{Content: "<p>secret</p>", Protected: true}, 100, protectedSummary},
+	}
+	for _, tt := range tests {
+		if got := postSummary(tt.post, tt.n); got != tt.want {
+			t.Errorf("%s: postSummary = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
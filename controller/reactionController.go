@@ -0,0 +1,259 @@
+package controller
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultCommentReactions are the emoji allowed when COMMENT_REACTIONS is not set
+var defaultCommentReactions = []string{"👍", "❤️", "😂", "😮", "😢", "🎉"}
+
+// commentReactions returns the emoji commenters may react with, from the
+// comma separated COMMENT_REACTIONS
+func commentReactions() []string {
+	var reactions []string
+	for _, emoji := range strings.Split(os.Getenv("COMMENT_REACTIONS"), ",") {
+		if emoji = strings.TrimSpace(emoji); emoji != "" {
+			reactions = append(reactions, emoji)
+		}
+	}
+	if len(reactions) == 0 {
+		return defaultCommentReactions
+	}
+	return reactions
+}
+
+func allowedReaction(emoji string) bool {
+	for _, allowed := range commentReactions() {
+		if emoji == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// viewerVotes limits the query to the votes of the request: by user for
+// logged-in users, by IP hash for anonymous ones
+func viewerVotes(query *gorm.DB, c *fiber.Ctx) *gorm.DB {
+	if user := middleware.CurrentUser(c); user != nil {
+		return query.Where("votes.user_id = ?", user.ID)
+	}
+	return query.Where("votes.user_id IS NULL AND votes.ip_hash = ?", util.HashIP(c.IP()))
+}
+
+// reactionSummary is the per-emoji count of the votes on some comments, and
+// the emoji the request reacted with
+type reactionSummary struct {
+	counts map[uint][]models.ReactionCount
+	mine   map[uint][]string
+}
+
+// loadReactions sums up the votes on the comments selected by commentIDs,
+// which may be a subquery
+func loadReactions(c *fiber.Ctx, commentIDs interface{}) (reactionSummary, error) {
+	summary := reactionSummary{
+		counts: make(map[uint][]models.ReactionCount),
+		mine:   make(map[uint][]string),
+	}
+
+	var counts []struct {
+		CommentID uint
+		Emoji     string
+		Count     int
+	}
+	err := database.DB.Model(&models.Vote{}).
+		Select("comment_id, emoji, COUNT(*) AS count").
+		Where("comment_id IN (?)", commentIDs).
+		Group("comment_id, emoji").
+		Order("count DESC, emoji").
+		Scan(&counts).Error
+	if err != nil {
+		return summary, err
+	}
+	for _, row := range counts {
+		summary.counts[row.CommentID] = append(summary.counts[row.CommentID],
+			models.ReactionCount{Emoji: row.Emoji, Count: row.Count})
+	}
+
+	var mine []models.Vote
+	err = viewerVotes(database.DB.Select("comment_id", "emoji"), c).
+		Where("comment_id IN (?)", commentIDs).
+		Order("id").
+		Find(&mine).Error
+	if err != nil {
+		return summary, err
+	}
+	for _, vote := range mine {
+		summary.mine[vote.CommentID] = append(summary.mine[vote.CommentID], vote.Emoji)
+	}
+	return summary, nil
+}
+
+// apply sets the reactions of the comment, as empty lists when it has none
+func (s reactionSummary) apply(comment *models.Comment) {
+	comment.Reactions = s.counts[comment.ID]
+	if comment.Reactions == nil {
+		comment.Reactions = []models.ReactionCount{}
+	}
+	comment.MyReactions = s.mine[comment.ID]
+	if comment.MyReactions == nil {
+		comment.MyReactions = []string{}
+	}
+}
+
+// findReactionComment loads the comment from the :commentId param if the
+// request may see it and react to it. It writes the error response and
+// returns false otherwise.
+func findReactionComment(c *fiber.Ctx) (models.Comment, bool) {
+	var comment models.Comment
+	commentID, err := strconv.ParseUint(c.Params("commentId"), 10, 32)
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid comment ID",
+		})
+		return comment, false
+	}
+
+	if err := visibleComments(database.DB, middleware.CurrentUser(c)).First(&comment, commentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Comment not found",
+			})
+			return comment, false
+		}
+		c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check comment",
+		})
+		return comment, false
+	}
+	if c.Params("postId") != strconv.FormatUint(uint64(comment.PostID), 10) {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Comment not found",
+		})
+		return comment, false
+	}
+	return comment, checkCommentPost(c, comment.PostID)
+}
+
+// reactionResponse answers a reaction change with the new reactions of the comment
+func reactionResponse(c *fiber.Ctx, status int, comment models.Comment, message string) error {
+	summary, err := loadReactions(c, []uint{comment.ID})
+	if err != nil {
+		log.Error("--> ReactionController: reactionResponse: Failed to get reactions: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get reactions",
+		})
+	}
+	summary.apply(&comment)
+	return c.Status(status).JSON(fiber.Map{
+		"message": message,
+		"data": fiber.Map{
+			"comment_id":   comment.ID,
+			"reactions":    comment.Reactions,
+			"my_reactions": comment.MyReactions,
+		},
+	})
+}
+
+// VoteComment adds an emoji reaction to a comment. Each user may react with
+// several different emoji, once each.
+func VoteComment(c *fiber.Ctx) error {
+	var data struct {
+		Emoji string `json:"emoji" form:"emoji"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to parse request body",
+		})
+	}
+	emoji := strings.TrimSpace(data.Emoji)
+	if !allowedReaction(emoji) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Emoji is not allowed",
+			"allowed": commentReactions(),
+		})
+	}
+
+	comment, ok := findReactionComment(c)
+	if !ok {
+		return nil
+	}
+
+	vote := models.Vote{CommentID: comment.ID, Emoji: emoji}
+	if user := middleware.CurrentUser(c); user != nil {
+		vote.UserID = &user.ID
+	} else {
+		vote.IPHash = util.HashIP(c.IP())
+	}
+	vote.SetVoter()
+
+	var count int64
+	err := viewerVotes(database.DB.Model(&models.Vote{}), c).
+		Where("comment_id = ? AND emoji = ?", comment.ID, emoji).
+		Count(&count).Error
+	if err != nil {
+		log.Error("--> ReactionController: VoteComment: Failed to check vote: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process vote",
+		})
+	}
+	if count > 0 {
+		return reactionResponse(c, fiber.StatusOK, comment, "Reaction already added")
+	}
+
+	// 동시에 들어온 같은 반응은 고유 인덱스에 걸려 추가되지 않음
+	result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&vote)
+	if result.Error != nil {
+		log.Error("--> ReactionController: VoteComment: Failed to create vote: ", result.Error)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create vote",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return reactionResponse(c, fiber.StatusOK, comment, "Reaction already added")
+	}
+	return reactionResponse(c, fiber.StatusCreated, comment, "Reaction added successfully")
+}
+
+// RemoveCommentReaction removes the request's reaction with the emoji query
+// param from a comment
+func RemoveCommentReaction(c *fiber.Ctx) error {
+	emoji := strings.TrimSpace(c.Query("emoji"))
+	if emoji == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Emoji is required",
+		})
+	}
+
+	comment, ok := findReactionComment(c)
+	if !ok {
+		return nil
+	}
+
+	// 반응은 기록을 남기지 않고 바로 삭제
+	result := viewerVotes(database.DB.Unscoped(), c).
+		Where("comment_id = ? AND emoji = ?", comment.ID, emoji).
+		Delete(&models.Vote{})
+	if result.Error != nil {
+		log.Error("--> ReactionController: RemoveCommentReaction: Failed to delete vote: ", result.Error)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove reaction",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Reaction not found",
+		})
+	}
+	return reactionResponse(c, fiber.StatusOK, comment, "Reaction removed successfully")
+}
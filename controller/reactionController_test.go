@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAllowedReaction(t *testing.T) {
+	tests := []struct {
+		env   string
+		emoji string
+		want  bool
+	}{
+		{"", "👍", true},
+		{"", "❤️", true},
+		{"", "👎", false},
+		{"", "", false},
+		{"👍, 👎 ,", "👎", true},
+		{"👍, 👎 ,", "🎉", false},
+		{" , ", "🎉", true}, // 비어 있으면 기본 목록
+	}
+	for _, tt := range tests {
+		t.Setenv("COMMENT_REACTIONS", tt.env)
+		if got := allowedReaction(tt.emoji); got != tt.want {
+			t.Errorf("COMMENT_REACTIONS=%q: allowedReaction(%q) = %t, want %t", tt.env, tt.emoji, got, tt.want)
+		}
+	}
+}
+
+func TestReactionSummaryApply(t *testing.T) {
+	summary := reactionSummary{
+		counts: map[uint][]models.ReactionCount{1: {{Emoji: "👍", Count: 2}}},
+		mine:   map[uint][]string{1: {"👍"}},
+	}
+	comment := models.Comment{}
+	comment.ID = 1
+	summary.apply(&comment)
+	if !reflect.DeepEqual(comment.Reactions, summary.counts[1]) || !reflect.DeepEqual(comment.MyReactions, []string{"👍"}) {
+		t.Errorf("comment 1: reactions %v, mine %v", comment.Reactions, comment.MyReactions)
+	}
+
+	// 반응이 없어도 JSON에서 null이 아닌 빈 배열
+	other := models.Comment{}
+	other.ID = 2
+	reactionSummary{}.apply(&other)
+	b, _ := json.Marshal(other)
+	if !strings.Contains(string(b), `"reactions":[]`) || !strings.Contains(string(b), `"my_reactions":[]`) {
+		t.Errorf("comment 2 JSON = %s, want empty reaction lists", b)
+	}
+}
+
+func TestViewerVotes(t *testing.T) {
+	db := useDryRunDB(t)
+	tests := []struct {
+		name string
+		user *models.User
+		want string
+		vars []interface{}
+	}{
+		{"user", &models.User{ID: 3}, "votes.user_id = ?", []interface{}{uint(3)}},
+		{"anonymous", nil, "votes.user_id IS NULL AND votes.ip_hash = ?", []interface{}{util.HashIP("0.0.0.0")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			var sql string
+			var vars []interface{}
+			app.Get("/", func(c *fiber.Ctx) error {
+				c.Locals("user", tt.user)
+				var votes []models.Vote
+				stmt := viewerVotes(db.Model(&models.Vote{}), c).Find(&votes).Statement
+				sql, vars = stmt.SQL.String(), stmt.Vars
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/", nil)); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(sql, tt.want) || !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("SQL = %q %v, want %q %v", sql, vars, tt.want, tt.vars)
+			}
+		})
+	}
+}
+
+func TestReactionRequestValidation(t *testing.T) {
+	app := fiber.New()
+	app.Post("/posts/:postId/comments/:commentId/vote", VoteComment)
+	app.Delete("/posts/:postId/comments/:commentId/vote", RemoveCommentReaction)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   string
+	}{
+		{"emoji not allowed", "POST", "/posts/1/comments/2/vote", `{"emoji":"💩"}`, "Emoji is not allowed"},
+		{"no emoji", "POST", "/posts/1/comments/2/vote", `{}`, "Emoji is not allowed"},
+		{"invalid comment ID", "POST", "/posts/1/comments/x/vote", `{"emoji":"👍"}`, "Invalid comment ID"},
+		{"remove without emoji", "DELETE", "/posts/1/comments/2/vote", "", "Emoji is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			json.NewDecoder(resp.Body).Decode(&body)
+			if resp.StatusCode != fiber.StatusBadRequest || body.Error != tt.want {
+				t.Errorf("status %d, error %q, want 400 %q", resp.StatusCode, body.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gorilla/feeds"
+)
+
+// rssHreflangFeed adds atom:link elements for the feed URL and the hreflang
+// alternates of the feed and of each item, which gorilla/feeds doesn't
+// write, to its RSS 2.0 feed
+type rssHreflangFeed struct {
+	*feeds.RssFeed
+	Links     []rssAtomLink
+	ItemLinks [][]rssAtomLink // by item index
+}
+
+type rssXML struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ContentNS string     `xml:"xmlns:content,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	*feeds.RssFeed
+	Links []rssAtomLink `xml:"atom:link"`
+	Items []rssItem     `xml:"item"`
+}
+
+type rssItem struct {
+	*feeds.RssItem
+	Links []rssAtomLink `xml:"atom:link"`
+}
+
+type rssAtomLink struct {
+	Href     string `xml:"href,attr"`
+	Rel      string `xml:"rel,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Hreflang string `xml:"hreflang,attr,omitempty"`
+}
+
+// FeedXml implements feeds.XmlFeed
+func (f *rssHreflangFeed) FeedXml() interface{} {
+	channel := *f.RssFeed
+	channel.Items = nil
+	items := make([]rssItem, len(f.RssFeed.Items))
+	for i, item := range f.RssFeed.Items {
+		items[i] = rssItem{RssItem: item, Links: f.ItemLinks[i]}
+	}
+	return &rssXML{
+		Version:   "2.0",
+		ContentNS: "http://purl.org/rss/1.0/modules/content/",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		Channel:   rssChannel{RssFeed: &channel, Links: f.Links, Items: items},
+	}
+}
+
+// RSSFeed generates an RSS feed of recent posts. With a lang param the feed
+// only has posts available in that language, translated where needed.
+func RSSFeed(c *fiber.Ctx) error {
+	var posts []models.Post
+	limit := 10 // Get the latest 10 posts
+
+	lang := normalizeLang(c.Query("lang"))
+	query := database.DB.Preload("User").
+		Where("hidden = ? OR hidden IS NULL", false)
+	if lang != "" {
+		query = query.Where("(lang = ? OR id IN (?))", lang,
+			database.DB.Model(&models.PostTranslation{}).Select("post_id").Where("lang = ?", lang))
+	}
+	result := query.Order("created_at DESC").
+		Limit(limit).
+		Find(&posts)
+
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching posts",
+		})
+	}
+
+	loadPostAuthors(posts)
+	// 항목별 hreflang을 위해 번역 전 원문 언어 기록
+	originals := make(map[uint]string, len(posts))
+	for _, post := range posts {
+		originals[post.ID] = post.Lang
+	}
+	if lang != "" {
+		applyTranslations(posts, []string{lang})
+	}
+	itemLangs := rssItemLanguages(posts, originals)
+	baseURL := siteBaseURL()
+
+	feed := &feeds.Feed{
+		Title:       "Our Journey",
+		Link:        &feeds.Link{Href: baseURL},
+		Description: "Recent posts from Our Journey",
+		Author:      &feeds.Author{Name: "Our Journey"},
+		Created:     time.Now(),
+	}
+	links := []rssAtomLink{{
+		Href: rssFeedURL(baseURL, lang),
+		Rel:  "self",
+		Type: "application/rss+xml",
+	}}
+	for _, feedLang := range rssFeedLanguages() {
+		links = append(links, rssAtomLink{
+			Href:     rssFeedURL(baseURL, feedLang),
+			Rel:      "alternate",
+			Type:     "application/rss+xml",
+			Hreflang: feedLang,
+		})
+	}
+
+	var itemLinks [][]rssAtomLink
+	for _, post := range posts {
+		// Limit the length of the title
+		title := truncateString(post.Title, 100)
+
+		// Limit the length of the description
+		description := postSummary(post, 300)
+
+		link := postURL(baseURL, post.ID, post.Lang, originals[post.ID])
+		item := &feeds.Item{
+			Title:       title,
+			Link:        &feeds.Link{Href: link},
+			Description: description,
+			Author:      &feeds.Author{Name: authorNames(post)},
+			Id:          link,
+			Created:     post.CreatedAt,
+		}
+		feed.Items = append(feed.Items, item)
+
+		var alternates []rssAtomLink
+		for _, itemLang := range itemLangs[post.ID] {
+			alternates = append(alternates, rssAtomLink{
+				Href:     postURL(baseURL, post.ID, itemLang, originals[post.ID]),
+				Rel:      "alternate",
+				Hreflang: itemLang,
+			})
+		}
+		itemLinks = append(itemLinks, alternates)
+	}
+
+	channel := (&feeds.Rss{Feed: feed}).RssFeed()
+	channel.Language = lang
+	rss, err := feeds.ToXML(&rssHreflangFeed{RssFeed: channel, Links: links, ItemLinks: itemLinks})
+	if err != nil {
+		log.Error("--> RSSController: RSSFeed: Failed to generate feed: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error generating RSS feed",
+		})
+	}
+
+	c.Set("Content-Type", "application/rss+xml; charset=utf-8")
+	return c.Send([]byte(rss))
+}
+
+// postURL links to the post in lang; the original has no lang param
+func postURL(baseURL string, postID uint, lang, original string) string {
+	if lang == original {
+		return fmt.Sprintf("%s/post/%d", baseURL, postID)
+	}
+	return fmt.Sprintf("%s/post/%d?lang=%s", baseURL, postID, url.QueryEscape(lang))
+}
+
+func rssFeedURL(baseURL, lang string) string {
+	if lang == "" {
+		return baseURL + "/api/v1/rss"
+	}
+	return baseURL + "/api/v1/rss?lang=" + url.QueryEscape(lang)
+}
+
+// rssItemLanguages returns the original and translated languages of each post
+func rssItemLanguages(posts []models.Post, originals map[uint]string) map[uint][]string {
+	langs := make(map[uint][]string, len(posts))
+	if len(posts) == 0 {
+		return langs
+	}
+	postIDs := make([]uint, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+		langs[post.ID] = []string{originals[post.ID]}
+	}
+
+	var translations []models.PostTranslation
+	database.DB.Select("post_id", "lang").Where("post_id IN ?", postIDs).Order("lang").Find(&translations)
+	for _, t := range translations {
+		langs[t.PostID] = append(langs[t.PostID], t.Lang)
+	}
+	return langs
+}
+
+// rssFeedLanguages returns every language with at least one post or translation
+func rssFeedLanguages() []string {
+	var postLangs, translationLangs []string
+	database.DB.Model(&models.Post{}).Where("hidden = ? OR hidden IS NULL", false).Distinct().Pluck("lang", &postLangs)
+	database.DB.Model(&models.PostTranslation{}).Distinct().Pluck("lang", &translationLangs)
+
+	seen := make(map[string]bool)
+	langs := []string{}
+	for _, lang := range append(postLangs, translationLangs...) {
+		if lang != "" && !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return langs
+}
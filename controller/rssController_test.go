@@ -0,0 +1,34 @@
+package controller
+
+import "testing"
+
+func TestPostURL(t *testing.T) {
+	tests := []struct {
+		lang, original string
+		want           string
+	}{
+		{"en", "en", "https://blog.example.com/post/3"},
+		{"ko", "en", "https://blog.example.com/post/3?lang=ko"},
+		{"zh-hant", "ko", "https://blog.example.com/post/3?lang=zh-hant"},
+	}
+	for _, tt := range tests {
+		if got := postURL("https://blog.example.com", 3, tt.lang, tt.original); got != tt.want {
+			t.Errorf("postURL(%q, %q) = %q, want %q", tt.lang, tt.original, got, tt.want)
+		}
+	}
+}
+
+func TestRSSFeedURL(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"", "https://blog.example.com/api/v1/rss"},
+		{"ko", "https://blog.example.com/api/v1/rss?lang=ko"},
+	}
+	for _, tt := range tests {
+		if got := rssFeedURL("https://blog.example.com", tt.lang); got != tt.want {
+			t.Errorf("rssFeedURL(%q) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
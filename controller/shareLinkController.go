@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultShareLinkTTL = 7 * 24 * time.Hour
+	maxShareLinkTTL     = 90 * 24 * time.Hour
+)
+
+// activeShareLink returns the share link of the post given by the share query
+// param or the X-Share-Token header, or nil when there is none or it expired
+func activeShareLink(c *fiber.Ctx, postID uint) *models.PostShareLink {
+	token := c.Query("share")
+	if token == "" {
+		token = c.Get("X-Share-Token")
+	}
+	if token == "" {
+		return nil
+	}
+
+	// 한 요청에서 여러 번 확인해도 한 번만 조회
+	key := fmt.Sprintf("shareLink%d", postID)
+	if link, ok := c.Locals(key).(*models.PostShareLink); ok {
+		return link
+	}
+	var link *models.PostShareLink
+	var found models.PostShareLink
+	err := database.DB.Where("token_hash = ? AND post_id = ?", util.HashSecretToken(token), postID).First(&found).Error
+	if err == nil && found.Active() {
+		link = &found
+		database.DB.Model(&found).UpdateColumn("last_used_at", time.Now())
+	}
+	c.Locals(key, link)
+	return link
+}
+
+// viewablePosts is visiblePosts for the post with postID, which a valid share
+// link also makes visible. Comment endpoints pass comments so only links that
+// allow comments count.
+func viewablePosts(c *fiber.Ctx, postID uint, comments bool) *gorm.DB {
+	query := database.DB.Model(&models.Post{})
+	if link := activeShareLink(c, postID); link != nil && (!comments || link.AllowComments) {
+		return query
+	}
+	return visiblePosts(query, middleware.CurrentUser(c))
+}
+
+// findSharingPost loads the post from the :id param and checks that the
+// current user may manage its share links
+func findSharingPost(c *fiber.Ctx) (models.Post, bool) {
+	var post models.Post
+	id, ok := postIDParam(c)
+	if !ok {
+		return post, false
+	}
+	if err := database.DB.First(&post, id).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+		return post, false
+	}
+	if !canManagePost(middleware.CurrentUser(c), post) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to share this post",
+		})
+		return post, false
+	}
+	return post, true
+}
+
+// GetShareLinks lists the share links of a post that are neither expired nor revoked
+func GetShareLinks(c *fiber.Ctx) error {
+	post, ok := findSharingPost(c)
+	if !ok {
+		return nil
+	}
+
+	var links []models.PostShareLink
+	if err := database.DB.Where("post_id = ? AND revoked_at IS NULL AND expires_at > ?", post.ID, time.Now()).
+		Order("created_at DESC").Find(&links).Error; err != nil {
+		log.Error("--> ShareLinkController: GetShareLinks: Failed to get share links: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching share links",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": links,
+	})
+}
+
+// CreateShareLink creates a share link for a post. The token is only
+// returned here; afterwards the link can only be revoked.
+func CreateShareLink(c *fiber.Ctx) error {
+	post, ok := findSharingPost(c)
+	if !ok {
+		return nil
+	}
+
+	var data struct {
+		Note          string `json:"note"`
+		AllowComments bool   `json:"allow_comments"`
+		ExpiresIn     int    `json:"expires_in"` // hours
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+	ttl := defaultShareLinkTTL
+	if data.ExpiresIn != 0 {
+		ttl = time.Duration(data.ExpiresIn) * time.Hour
+	}
+	if ttl <= 0 || ttl > maxShareLinkTTL {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": fmt.Sprintf("expires_in must be between 1 and %d hours", int(maxShareLinkTTL.Hours())),
+		})
+	}
+
+	token, hash, err := util.NewSecretToken()
+	if err != nil {
+		log.Error("--> ShareLinkController: CreateShareLink: Failed to generate token: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to create share link",
+		})
+	}
+	link := models.PostShareLink{
+		PostID:        post.ID,
+		TokenHash:     hash,
+		TokenHint:     token[:6],
+		Note:          strings.TrimSpace(data.Note),
+		AllowComments: data.AllowComments,
+		CreatedBy:     middleware.CurrentUser(c).ID,
+		ExpiresAt:     time.Now().Add(ttl),
+	}
+	if err := database.DB.Create(&link).Error; err != nil {
+		log.Error("--> ShareLinkController: CreateShareLink: Failed to create share link: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to create share link",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data":    link,
+		"token":   token,
+		"url":     fmt.Sprintf("%s/post/%d?share=%s", siteBaseURL(), post.ID, token),
+		"message": "Share link created successfully",
+	})
+}
+
+// RevokeShareLink stops a share link from working
+func RevokeShareLink(c *fiber.Ctx) error {
+	post, ok := findSharingPost(c)
+	if !ok {
+		return nil
+	}
+
+	result := database.DB.Model(&models.PostShareLink{}).
+		Where("id = ? AND post_id = ? AND revoked_at IS NULL", c.Params("linkId"), post.ID).
+		UpdateColumn("revoked_at", time.Now())
+	if result.Error != nil {
+		log.Error("--> ShareLinkController: RevokeShareLink: Failed to revoke share link: ", result.Error)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to revoke share link",
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Share link not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Share link revoked successfully",
+	})
+}
@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestActiveShareLink(t *testing.T) {
+	link := &models.PostShareLink{ID: 9, PostID: 1}
+	tests := []struct {
+		name   string
+		target string
+		header string
+		want   *models.PostShareLink
+	}{
+		{"query", "/?share=abc", "", link},
+		{"header", "/", "abc", link},
+		// 토큰이 없으면 조회한 링크가 있어도 nil
+		{"no token", "/", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			var got *models.PostShareLink
+			app.Get("/", func(c *fiber.Ctx) error {
+				// 이미 조회한 링크는 데이터베이스를 다시 조회하지 않는다
+				c.Locals("shareLink1", link)
+				got = activeShareLink(c, 1)
+				return nil
+			})
+			req := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Share-Token", tt.header)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("activeShareLink = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
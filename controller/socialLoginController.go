@@ -167,6 +167,7 @@ func socialCallback(c *fiber.Ctx, oauthConfig *oauth2.Config, userInfoURL string
 	result := database.DB.Where("email = ?", user.Email).First(&existingUser)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
+			user.Role = models.RoleCommenter
 			if err := database.DB.Create(&user).Error; err != nil {
 				log.Printf("Failed to create user: %v", err)
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -180,13 +181,22 @@ func socialCallback(c *fiber.Ctx, oauthConfig *oauth2.Config, userInfoURL string
 			})
 		}
 	} else {
-		user.ID = existingUser.ID
-		if err := database.DB.Save(&user).Error; err != nil {
+		if existingUser.Blocked {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "User is blocked",
+			})
+		}
+		// Only refresh the profile; keep the password, role and other settings
+		existingUser.FirstName = user.FirstName
+		existingUser.LastName = user.LastName
+		existingUser.Picture = user.Picture
+		if err := database.DB.Save(&existingUser).Error; err != nil {
 			log.Printf("Failed to update user: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to update user",
 			})
 		}
+		user = existingUser
 	}
 
 	// Generate JWT token
@@ -0,0 +1,329 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+var langPattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+// normalizeLang lowercases a language tag such as en_US and returns "" when
+// it is not a valid tag
+func normalizeLang(lang string) string {
+	lang = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(lang), "_", "-"))
+	if !langPattern.MatchString(lang) {
+		return ""
+	}
+	return lang
+}
+
+// requestedLanguages returns the languages asked for by the lang param or
+// the Accept-Language header, most preferred first. A regional tag is
+// followed by its primary language so en-US also matches en.
+func requestedLanguages(c *fiber.Ctx) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+	var tags []weighted
+	if lang := normalizeLang(c.Query("lang")); lang != "" {
+		tags = append(tags, weighted{lang, 2})
+	} else {
+		for _, part := range strings.Split(c.Get(fiber.HeaderAcceptLanguage), ",") {
+			fields := strings.Split(part, ";")
+			q := 1.0
+			for _, param := range fields[1:] {
+				if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+					q, _ = strconv.ParseFloat(v, 64)
+				}
+			}
+			if lang := normalizeLang(fields[0]); lang != "" && q > 0 {
+				tags = append(tags, weighted{lang, q})
+			}
+		}
+		sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	}
+
+	langs := []string{}
+	seen := make(map[string]bool)
+	add := func(lang string) {
+		if !seen[lang] {
+			seen[lang] = true
+			langs = append(langs, lang)
+		}
+	}
+	for _, tag := range tags {
+		add(tag.lang)
+		if primary, _, ok := strings.Cut(tag.lang, "-"); ok {
+			add(primary)
+		}
+	}
+	return langs
+}
+
+// applyTranslations replaces the title, content and slug of each post with
+// the first requested language it is available in. Posts fall back to the
+// original when no requested translation exists.
+func applyTranslations(posts []models.Post, langs []string) {
+	if len(posts) == 0 || len(langs) == 0 {
+		return
+	}
+	postIDs := make([]uint, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	var translations []models.PostTranslation
+	database.DB.Where("post_id IN ? AND lang IN ?", postIDs, langs).Find(&translations)
+	if len(translations) == 0 {
+		return
+	}
+	byPost := make(map[uint]map[string]models.PostTranslation)
+	for _, t := range translations {
+		if byPost[t.PostID] == nil {
+			byPost[t.PostID] = make(map[string]models.PostTranslation)
+		}
+		byPost[t.PostID][t.Lang] = t
+	}
+
+	for i := range posts {
+		for _, lang := range langs {
+			if lang == posts[i].Lang {
+				break
+			}
+			if t, ok := byPost[posts[i].ID][lang]; ok {
+				posts[i].Title = t.Title
+				posts[i].Content = t.Content
+				posts[i].Slug = t.Slug
+				posts[i].Lang = t.Lang
+				break
+			}
+		}
+	}
+}
+
+// loadPostLanguages fills Languages with the original followed by the translations
+func loadPostLanguages(post *models.Post) {
+	var translations []models.PostTranslation
+	database.DB.Select("lang", "title", "slug").Where("post_id = ?", post.ID).Order("lang").Find(&translations)
+
+	post.Languages = []models.PostLanguage{{
+		Lang:     post.Lang,
+		Title:    post.Title,
+		Slug:     post.Slug,
+		Original: true,
+	}}
+	for _, t := range translations {
+		post.Languages = append(post.Languages, models.PostLanguage{
+			Lang:  t.Lang,
+			Title: t.Title,
+			Slug:  t.Slug,
+		})
+	}
+}
+
+// uniqueTranslationSlug slugifies s and appends the post ID when another
+// translation in the same language already uses the slug
+func uniqueTranslationSlug(s, lang string, postID uint) string {
+	slug := util.Slugify(s)
+	if slug == "" {
+		return fmt.Sprintf("%d-%s", postID, lang)
+	}
+	var count int64
+	database.DB.Model(&models.PostTranslation{}).Where("lang = ? AND slug = ? AND post_id <> ?", lang, slug, postID).Count(&count)
+	if count > 0 {
+		slug = fmt.Sprintf("%s-%d", slug, postID)
+	}
+	return slug
+}
+
+// GetTranslations lists the translations of a post
+func GetTranslations(c *fiber.Ctx) error {
+	var post models.Post
+	id, _ := c.ParamsInt("id")
+	if err := viewablePosts(c, uint(id), false).Where("id = ?", id).First(&post).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+
+	var translations []models.PostTranslation
+	if err := database.DB.Where("post_id = ?", post.ID).Order("lang").Find(&translations).Error; err != nil {
+		log.Error("--> TranslationController: GetTranslations: Failed to get translations: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching translations",
+		})
+	}
+	if !canReadPost(c, post) {
+		for i := range translations {
+			translations[i].Content = ""
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data": translations,
+		"meta": fiber.Map{
+			"original": post.Lang,
+		},
+	})
+}
+
+// SaveTranslation creates or updates the translation of a post in the :lang language
+func SaveTranslation(c *fiber.Ctx) error {
+	lang := normalizeLang(c.Params("lang"))
+	if lang == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid language code",
+		})
+	}
+
+	id, ok := postIDParam(c)
+	if !ok {
+		return nil
+	}
+	var post models.Post
+	if err := database.DB.First(&post, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+	if !canEditPost(middleware.CurrentUser(c), post) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to update this post",
+		})
+	}
+	if lang == post.Lang {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "The original post is already in this language",
+		})
+	}
+
+	var data struct {
+		Title   string `json:"title" form:"title"`
+		Content string `json:"content" form:"content"`
+		Slug    string `json:"slug" form:"slug"`
+	}
+	if err := c.BodyParser(&data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Failed to parse request body",
+		})
+	}
+	if strings.TrimSpace(data.Title) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "title is required",
+		})
+	}
+
+	var translation models.PostTranslation
+	database.DB.Where("post_id = ? AND lang = ?", post.ID, lang).First(&translation)
+	created := translation.ID == 0
+
+	slug := data.Slug
+	if slug == "" {
+		slug = translation.Slug
+	}
+	if slug == "" {
+		slug = data.Title
+	}
+	translation.PostID = post.ID
+	translation.Lang = lang
+	translation.Title = data.Title
+	translation.Content = data.Content
+	translation.Slug = uniqueTranslationSlug(slug, lang, post.ID)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&translation).Error; err != nil {
+			return err
+		}
+		return touchPost(tx, &post)
+	})
+	if err != nil {
+		log.Error("--> TranslationController: SaveTranslation: Failed to save translation: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to save translation",
+		})
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	c.Set(fiber.HeaderETag, postETag(post))
+	return c.Status(status).JSON(fiber.Map{
+		"data":    translation,
+		"message": "Translation saved successfully",
+	})
+}
+
+// touchPost bumps the version and update time of a post whose translations
+// changed, so that cached copies and editors holding the old version see it
+func touchPost(tx *gorm.DB, post *models.Post) error {
+	now := time.Now()
+	err := tx.Model(post).UpdateColumns(map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": now,
+	}).Error
+	if err != nil {
+		return err
+	}
+	post.Version++
+	post.UpdatedAt = &now
+	return nil
+}
+
+// DeleteTranslation deletes the translation of a post in the :lang language
+func DeleteTranslation(c *fiber.Ctx) error {
+	id, ok := postIDParam(c)
+	if !ok {
+		return nil
+	}
+	var post models.Post
+	if err := database.DB.First(&post, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found",
+		})
+	}
+	if !canEditPost(middleware.CurrentUser(c), post) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to update this post",
+		})
+	}
+
+	var deleted int64
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("post_id = ? AND lang = ?", post.ID, normalizeLang(c.Params("lang"))).Delete(&models.PostTranslation{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return touchPost(tx, &post)
+	})
+	if err != nil {
+		log.Error("--> TranslationController: DeleteTranslation: Failed to delete translation: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to delete translation",
+		})
+	}
+	if deleted == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Translation not found",
+		})
+	}
+
+	c.Set(fiber.HeaderETag, postETag(post))
+	return c.JSON(fiber.Map{
+		"message": "Translation deleted successfully",
+	})
+}
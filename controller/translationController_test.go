@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestNormalizeLang(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"en", "en"},
+		{" KO ", "ko"},
+		{"en_US", "en-us"},
+		{"zh-Hant", "zh-hant"},
+		{"fil", "fil"},
+		{"e", ""},
+		{"english", ""},
+		{"en-", ""},
+		{"en-us-x", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeLang(tt.lang); got != tt.want {
+			t.Errorf("normalizeLang(%q) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestRequestedLanguages(t *testing.T) {
+	app := fiber.New()
+	var got []string
+	app.Get("/", func(c *fiber.Ctx) error {
+		got = requestedLanguages(c)
+		return nil
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		want   []string
+	}{
+		{"none", "", "", []string{}},
+		{"lang param", "?lang=ko", "en", []string{"ko"}},
+		{"regional lang param", "?lang=en_GB", "", []string{"en-gb", "en"}},
+		// 잘못된 lang 파라미터는 Accept-Language로 넘어간다
+		{"invalid lang param", "?lang=english", "ja", []string{"ja"}},
+		{"header order", "", "ko, en", []string{"ko", "en"}},
+		{"header quality", "", "en;q=0.5, ko;q=0.9, ja", []string{"ja", "ko", "en"}},
+		{"regional header", "", "en-US, ko;q=0.8", []string{"en-us", "en", "ko"}},
+		{"duplicate primary", "", "en-US, en-GB;q=0.9, en;q=0.8", []string{"en-us", "en", "en-gb"}},
+		{"zero quality", "", "ko, fr;q=0", []string{"ko"}},
+		{"wildcard", "", "*, ko;q=0.5", []string{"ko"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set(fiber.HeaderAcceptLanguage, tt.accept)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requestedLanguages = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// findTrashedPost loads the deleted post from the :id param and checks that
+// the current user may manage it. It writes the error response and returns
+// false otherwise.
+func findTrashedPost(c *fiber.Ctx) (models.Post, bool) {
+	var post models.Post
+	if err := database.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", c.Params("id")).First(&post).Error; err != nil {
+		c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Post not found in trash",
+		})
+		return post, false
+	}
+	if !canManagePost(middleware.CurrentUser(c), post) {
+		c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"message": "You don't have permission to manage this post",
+		})
+		return post, false
+	}
+	return post, true
+}
+
+// TrashPosts lists the deleted posts of the current user. Users who may edit
+// any post see the whole trash.
+func TrashPosts(c *fiber.Ctx) error {
+	user := middleware.CurrentUser(c)
+	query := database.DB.Unscoped().Preload("User").Where("deleted_at IS NOT NULL")
+	if !user.Can(models.PermEditAnyPost) {
+		query = query.Where("user_id = ?", user.ID)
+	}
+
+	var posts []models.Post
+	if err := query.Order("deleted_at DESC").Find(&posts).Error; err != nil {
+		log.Error("--> TrashController: TrashPosts: Failed to get trash: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Error fetching posts",
+		})
+	}
+
+	// 자동 영구 삭제 예정 시각
+	retention := util.TrashRetention()
+	data := make([]fiber.Map, len(posts))
+	for i, post := range posts {
+		var purgeAt *time.Time
+		if retention > 0 {
+			t := post.DeletedAt.Time.Add(retention)
+			purgeAt = &t
+		}
+		data[i] = fiber.Map{
+			"post":     post,
+			"purge_at": purgeAt,
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"data": data,
+	})
+}
+
+// RestorePost moves a deleted post out of the trash
+func RestorePost(c *fiber.Ctx) error {
+	post, ok := findTrashedPost(c)
+	if !ok {
+		return nil
+	}
+
+	// 삭제된 동안 다른 포스트가 slug를 가져갔을 수 있음
+	slug := post.Slug
+	if slug == "" {
+		slug = post.Title
+	}
+	updates := map[string]interface{}{
+		"deleted_at": nil,
+		"slug":       util.UniquePostSlug(database.DB, slug, post.ID),
+	}
+	if err := database.DB.Unscoped().Model(&post).UpdateColumns(updates).Error; err != nil {
+		log.Error("--> TrashController: RestorePost: Failed to restore post: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to restore post",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Post restored successfully",
+	})
+}
+
+// PurgePost permanently deletes a post in the trash with its comments and files
+func PurgePost(c *fiber.Ctx) error {
+	post, ok := findTrashedPost(c)
+	if !ok {
+		return nil
+	}
+
+	if err := util.PurgePost(database.DB, post.ID, "uploads"); err != nil {
+		log.Error("--> TrashController: PurgePost: Failed to purge post: ", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": "Unable to purge post",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Post purged successfully",
+	})
+}
@@ -1,8 +1,11 @@
 package database
 
 import (
+	"fmt"
 	"log"
+	"mime"
 	"os"
+	"path/filepath"
 
 	"github.com/bloomingFlower/blog-backend/models"
 	"github.com/joho/godotenv"
@@ -25,6 +28,7 @@ func Connect() {
 		log.Println("Database connected successfully")
 	}
 	DB = database
+	migratePostSlugs()
 	err = database.AutoMigrate(
 		&models.AboutInfo{},
 		&models.Contact{},
@@ -35,8 +39,65 @@ func Connect() {
 		&models.APILog{},
 		&models.Comment{},
 		&models.Vote{},
+		&models.CommentMention{},
+		&models.CommentPolicy{},
+		&models.CommentAudit{},
+		&models.CommentRevision{},
+		&models.SpamToken{},
+		&models.PostImport{},
+		&models.PostCollaborator{},
+		&models.PostTranslation{},
+		&models.PostAttachment{},
+		&models.PostShareLink{},
+		&models.PostDailyView{},
+		&models.Notification{},
+		&models.EmailMessage{},
 	)
 	if err != nil {
 		log.Fatal("Error migrating database: ", err)
 	}
+	migratePostFiles()
+}
+
+// migratePostSlugs prepares posts for the unique slug index: empty slugs
+// become NULL and the older non-unique index is dropped
+func migratePostSlugs() {
+	migrator := DB.Migrator()
+	if !migrator.HasColumn(&models.Post{}, "slug") {
+		return
+	}
+	if err := DB.Unscoped().Model(&models.Post{}).Where("slug = ''").UpdateColumn("slug", gorm.Expr("NULL")).Error; err != nil {
+		log.Printf("Warning: Error clearing empty post slugs: %v", err)
+	}
+	if migrator.HasIndex(&models.Post{}, "idx_posts_slug") {
+		if err := migrator.DropIndex(&models.Post{}, "idx_posts_slug"); err != nil {
+			log.Printf("Warning: Error dropping the old post slug index: %v", err)
+		}
+	}
+}
+
+// migratePostFiles creates attachments for posts that only have the single
+// File of older versions
+func migratePostFiles() {
+	var posts []models.Post
+	DB.Where("file <> '' AND NOT EXISTS (SELECT 1 FROM post_attachments WHERE post_attachments.post_id = posts.id)").Find(&posts)
+	for _, post := range posts {
+		filename := filepath.Base(post.File)
+		attachment := models.PostAttachment{
+			PostID:   post.ID,
+			Filename: filename,
+			Name:     filename,
+			MimeType: mime.TypeByExtension(filepath.Ext(filename)),
+		}
+		// 업로드 시 붙인 8자리 임의 접두어 제거
+		if len(filename) > 9 && filename[8] == '-' {
+			attachment.Name = filename[9:]
+		}
+		if info, err := os.Stat(filepath.Join("uploads", fmt.Sprint(post.ID), filename)); err == nil {
+			attachment.Size = info.Size()
+		}
+		if err := DB.Create(&attachment).Error; err != nil {
+			log.Printf("Warning: Error migrating file of post %d: %v", post.ID, err)
+		}
+	}
 }
@@ -0,0 +1,96 @@
+// Package importer converts posts from other blogging tools into models.Post.
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Source names stored in models.PostImport
+const (
+	SourceMarkdown  = "markdown"
+	SourceWordPress = "wordpress"
+	SourceExport    = "export"
+)
+
+// Document is a post parsed from an import source
+type Document struct {
+	Source      string
+	SourceID    string
+	Title       string
+	Slug        string
+	Content     string // HTML
+	Date        time.Time
+	Updated     *time.Time
+	Tags        []string
+	Categories  []string
+	Author      string
+	AuthorEmail string
+	Hidden      bool
+
+	// Attachments are files (relative to Dir) copied into uploads/<postID>
+	// as they are; File names the one stored in models.Post.File
+	Attachments []string
+	File        string
+
+	// Directories used to resolve relative image paths
+	Dir  string
+	Root string
+}
+
+// Checksum identifies the imported content so unchanged documents can be skipped
+func (d Document) Checksum() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%t\x00%d\x00%s\x00%s",
+		d.Title, d.Slug, d.Content, strings.Join(d.Tags, ","), strings.Join(d.Categories, ","),
+		d.AuthorEmail+d.Author, d.Hidden, d.Date.Unix(), strings.Join(d.Attachments, ","), d.File)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Category returns the first category, since a post has only one
+func (d Document) Category() string {
+	if len(d.Categories) == 0 {
+		return ""
+	}
+	return d.Categories[0]
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// cleanList trims the values and drops empty and duplicate ones
+func cleanList(values []string) []string {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		result = append(result, v)
+	}
+	return result
+}
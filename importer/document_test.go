@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Time
+		ok   bool
+	}{
+		{"2021-03-04T10:20:30Z", time.Date(2021, 3, 4, 10, 20, 30, 0, time.UTC), true},
+		{"2021-03-04T10:20:30", time.Date(2021, 3, 4, 10, 20, 30, 0, time.UTC), true},
+		{"2021-03-04 10:20:30 +0900", time.Date(2021, 3, 4, 1, 20, 30, 0, time.UTC), true},
+		{" 2021-03-04 ", time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), true},
+		{"Thu, 04 Mar 2021 10:20:30 +0000", time.Date(2021, 3, 4, 10, 20, 30, 0, time.UTC), true},
+		{"yesterday", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDate(tt.s)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v, %t, want %v, %t", tt.s, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCleanList(t *testing.T) {
+	got := cleanList([]string{" go ", "", "web", "go", "  "})
+	if want := []string{"go", "web"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cleanList = %q, want %q", got, want)
+	}
+}
+
+func TestDocumentChecksum(t *testing.T) {
+	doc := Document{Title: "Hello", Content: "<p>x</p>", Tags: []string{"go"}, Date: time.Unix(100, 0)}
+	same := doc
+	same.Dir = "elsewhere"
+
+	changes := map[string]func(d *Document){
+		"title":   func(d *Document) { d.Title = "Bye" },
+		"content": func(d *Document) { d.Content = "<p>y</p>" },
+		"tags":    func(d *Document) { d.Tags = []string{"web"} },
+		"hidden":  func(d *Document) { d.Hidden = true },
+		"date":    func(d *Document) { d.Date = time.Unix(200, 0) },
+		"file":    func(d *Document) { d.File = "a.pdf" },
+	}
+	if doc.Checksum() != same.Checksum() {
+		t.Error("checksum depends on the directory")
+	}
+	for name, change := range changes {
+		changed := doc
+		change(&changed)
+		if changed.Checksum() == doc.Checksum() {
+			t.Errorf("checksum ignores the %s", name)
+		}
+	}
+}
+
+func TestDocumentCategory(t *testing.T) {
+	if got := (Document{}).Category(); got != "" {
+		t.Errorf("Category = %q, want empty", got)
+	}
+	if got := (Document{Categories: []string{"notes", "diary"}}).Category(); got != "notes" {
+		t.Errorf("Category = %q, want notes", got)
+	}
+}
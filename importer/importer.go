@@ -0,0 +1,412 @@
+package importer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
+	"gorm.io/gorm"
+)
+
+// Actions reported for each document
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionUnchanged = "unchanged"
+	ActionFailed    = "failed"
+)
+
+const maxImageSize = 10 * 1024 * 1024 // 10 MB, same as uploads
+
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+}
+
+var imgSrc = regexp.MustCompile(`(<img\b[^>]*?\bsrc=)("([^"]*)"|'([^']*)')`)
+
+// linkAttr matches the src and href attributes of any tag, which are the
+// links the archive export points at files/
+var linkAttr = regexp.MustCompile(`(<[a-zA-Z][^>]*?\b(?:src|href)=)("([^"]*)"|'([^']*)')`)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Options control how documents are imported
+type Options struct {
+	DryRun bool
+	// DefaultUserID is the author of documents whose author is not a known user
+	DefaultUserID uint
+	// FetchRemote downloads images referenced by http(s) URLs
+	FetchRemote bool
+	// UploadDir is the directory holding uploads/<postID>
+	UploadDir string
+	// BaseURL is used to build the rewritten image links
+	BaseURL string
+}
+
+// ReportItem is the result of importing a single document
+type ReportItem struct {
+	Source   string   `json:"source"`
+	SourceID string   `json:"source_id"`
+	Title    string   `json:"title"`
+	Action   string   `json:"action"`
+	PostID   uint     `json:"post_id,omitempty"`
+	Images   int      `json:"images"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Report summarizes an import run
+type Report struct {
+	DryRun    bool         `json:"dry_run"`
+	Created   int          `json:"created"`
+	Updated   int          `json:"updated"`
+	Unchanged int          `json:"unchanged"`
+	Failed    int          `json:"failed"`
+	Items     []ReportItem `json:"items"`
+}
+
+// Import creates or updates a post for every document. Documents are keyed
+// by their source and source ID, so running the same import twice is safe.
+func Import(docs []Document, opts Options) *Report {
+	if opts.UploadDir == "" {
+		opts.UploadDir = "uploads"
+	}
+	report := &Report{DryRun: opts.DryRun, Items: []ReportItem{}}
+
+	for _, doc := range docs {
+		item := importDocument(doc, opts)
+		switch item.Action {
+		case ActionCreate:
+			report.Created++
+		case ActionUpdate:
+			report.Updated++
+		case ActionUnchanged:
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+		report.Items = append(report.Items, item)
+	}
+	return report
+}
+
+func importDocument(doc Document, opts Options) ReportItem {
+	item := ReportItem{Source: doc.Source, SourceID: doc.SourceID, Title: doc.Title}
+	fail := func(err error) ReportItem {
+		item.Action = ActionFailed
+		item.Error = err.Error()
+		return item
+	}
+
+	if doc.SourceID == "" {
+		return fail(errors.New("document has no source ID"))
+	}
+	if doc.Title == "" {
+		return fail(errors.New("document has no title"))
+	}
+
+	var existing models.PostImport
+	var post models.Post
+	err := database.DB.Where("source = ? AND source_id = ?", doc.Source, doc.SourceID).First(&existing).Error
+	switch {
+	case err == nil:
+		if database.DB.First(&post, existing.PostID).Error == nil {
+			item.PostID = post.ID
+			item.Action = ActionUpdate
+		} else {
+			item.Action = ActionCreate
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		item.Action = ActionCreate
+	default:
+		return fail(err)
+	}
+
+	checksum := doc.Checksum()
+	if item.Action == ActionUpdate && existing.Checksum == checksum {
+		item.Action = ActionUnchanged
+		return item
+	}
+
+	userID, warning := resolveAuthor(doc, opts.DefaultUserID)
+	if warning != "" {
+		item.Warnings = append(item.Warnings, warning)
+	}
+	if userID == 0 {
+		return fail(errors.New("no author for document"))
+	}
+
+	attached := make(map[string]bool)
+	for _, rel := range doc.Attachments {
+		attached[filepath.ToSlash(rel)] = true
+	}
+	var images []string
+	for _, src := range findImages(doc.Content) {
+		if !attached[src] {
+			images = append(images, src)
+		}
+	}
+	item.Images = len(images) + len(doc.Attachments)
+
+	if opts.DryRun {
+		for _, src := range images {
+			// Remote images are only checked when they are actually copied
+			if isRemote(src) && opts.FetchRemote {
+				continue
+			}
+			r, err := openImage(doc, src, opts.FetchRemote)
+			if err != nil {
+				item.Warnings = append(item.Warnings, err.Error())
+				continue
+			}
+			r.Close()
+		}
+		return item
+	}
+
+	var copied []string
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		post.Title = doc.Title
+		post.Content = doc.Content
+		post.Tags = strings.Join(doc.Tags, ",")
+		post.Category = doc.Category()
+		post.Hidden = doc.Hidden
+		post.UserID = userID
+		post.UpdatedAt = doc.Updated
+		if !doc.Date.IsZero() {
+			post.CreatedAt = doc.Date
+		}
+		// Editors holding the old version must not overwrite the import
+		if post.ID != 0 {
+			post.Version++
+		}
+
+		if post.ID == 0 {
+			// Versions start at 1 like the column default
+			post.Version = 1
+			if err := tx.Create(&post).Error; err != nil {
+				return err
+			}
+		}
+
+		// Images are stored per post, so they are copied once the ID is known
+		dirPath := filepath.Join(opts.UploadDir, fmt.Sprint(post.ID))
+		rewrites := make(map[string]string)
+		for _, rel := range doc.Attachments {
+			name, err := copyAttachment(filepath.Join(doc.Dir, rel), dirPath)
+			if err != nil {
+				return err
+			}
+			copied = append(copied, filepath.Join(dirPath, name))
+			rewrites[filepath.ToSlash(rel)] = fmt.Sprintf("%s/api/v1/uploads/%d/%s", opts.BaseURL, post.ID, name)
+		}
+		if doc.File != "" {
+			post.File = fmt.Sprintf("files/%d/%s", post.ID, doc.File)
+		}
+		for _, src := range images {
+			name, err := copyImage(doc, src, dirPath, opts.FetchRemote)
+			if err != nil {
+				item.Warnings = append(item.Warnings, err.Error())
+				continue
+			}
+			copied = append(copied, filepath.Join(dirPath, name))
+			rewrites[src] = fmt.Sprintf("%s/api/v1/uploads/%d/%s", opts.BaseURL, post.ID, name)
+		}
+		post.Content = rewriteLinks(post.Content, rewrites)
+
+		slug := doc.Slug
+		if slug == "" {
+			slug = doc.Title
+		}
+		post.Slug = util.UniquePostSlug(tx, slug, post.ID)
+
+		if err := tx.Save(&post).Error; err != nil {
+			return err
+		}
+
+		existing.Source = doc.Source
+		existing.SourceID = doc.SourceID
+		existing.PostID = post.ID
+		existing.Checksum = checksum
+		return tx.Save(&existing).Error
+	})
+	if err != nil {
+		for _, file := range copied {
+			os.Remove(file)
+		}
+		return fail(err)
+	}
+
+	item.PostID = post.ID
+	return item
+}
+
+// resolveAuthor maps the document author to a user by email, then by name
+func resolveAuthor(doc Document, defaultUserID uint) (uint, string) {
+	var user models.User
+	if doc.AuthorEmail != "" {
+		if database.DB.Where("email = ?", doc.AuthorEmail).First(&user).Error == nil {
+			return user.ID, ""
+		}
+	}
+	if doc.Author != "" {
+		if database.DB.Where("CONCAT(first_name, ' ', last_name) = ? OR first_name = ?", doc.Author, doc.Author).
+			First(&user).Error == nil {
+			return user.ID, ""
+		}
+		return defaultUserID, fmt.Sprintf("author %q not found, using default author", doc.Author)
+	}
+	return defaultUserID, ""
+}
+
+// findImages returns the distinct image sources that are not already uploads
+func findImages(content string) []string {
+	seen := make(map[string]bool)
+	var images []string
+	for _, m := range imgSrc.FindAllStringSubmatch(content, -1) {
+		src := m[3] + m[4]
+		if src == "" || seen[src] || strings.HasPrefix(src, "data:") ||
+			strings.Contains(src, "/api/v1/uploads/") || strings.Contains(src, "/api/v1/download/") {
+			continue
+		}
+		seen[src] = true
+		images = append(images, src)
+	}
+	return images
+}
+
+// rewriteLinks points the image sources and links found in rewrites at the
+// copied files
+func rewriteLinks(content string, rewrites map[string]string) string {
+	return linkAttr.ReplaceAllStringFunc(content, func(tag string) string {
+		m := linkAttr.FindStringSubmatch(tag)
+		if to, ok := rewrites[m[3]+m[4]]; ok {
+			return m[1] + `"` + to + `"`
+		}
+		return tag
+	})
+}
+
+func isRemote(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") || strings.HasPrefix(src, "//")
+}
+
+// openImage opens a referenced image. Local paths are resolved relative to
+// the document and the repository root (including Hugo's static directory)
+// and may not escape the root.
+func openImage(doc Document, src string, fetchRemote bool) (io.ReadCloser, error) {
+	if isRemote(src) {
+		if !fetchRemote {
+			return nil, fmt.Errorf("remote image not downloaded: %s", src)
+		}
+		if strings.HasPrefix(src, "//") {
+			src = "https:" + src
+		}
+		resp, err := httpClient.Get(src)
+		if err != nil {
+			return nil, fmt.Errorf("download %s: %v", src, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("download %s: %s", src, resp.Status)
+		}
+		return resp.Body, nil
+	}
+
+	if doc.Root == "" {
+		return nil, fmt.Errorf("image not found: %s", src)
+	}
+	rel := strings.SplitN(strings.SplitN(src, "?", 2)[0], "#", 2)[0]
+	var candidates []string
+	if strings.HasPrefix(rel, "/") {
+		candidates = []string{filepath.Join(doc.Root, "static", rel), filepath.Join(doc.Root, rel)}
+	} else {
+		candidates = []string{filepath.Join(doc.Dir, rel), filepath.Join(doc.Root, "static", rel), filepath.Join(doc.Root, rel)}
+	}
+	root := filepath.Clean(doc.Root) + string(os.PathSeparator)
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(candidate, root) {
+			continue
+		}
+		if f, err := os.Open(candidate); err == nil {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("image not found: %s", src)
+}
+
+// copyImage copies the image into dirPath and returns the new file name.
+// Names are derived from the source so re-imports overwrite the same file.
+func copyImage(doc Document, src, dirPath string, fetchRemote bool) (string, error) {
+	base := path.Base(strings.SplitN(strings.SplitN(src, "?", 2)[0], "#", 2)[0])
+	ext := strings.ToLower(path.Ext(base))
+	if !imageExtensions[ext] {
+		return "", fmt.Errorf("unsupported image type: %s", src)
+	}
+
+	r, err := openImage(doc, src, fetchRemote)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return "", err
+	}
+	sum := sha1.Sum([]byte(src))
+	name := hex.EncodeToString(sum[:4]) + "-" + util.Slugify(strings.TrimSuffix(base, path.Ext(base))) + ext
+	target := filepath.Join(dirPath, name)
+
+	f, err := os.Create(target)
+	if err != nil {
+		return "", err
+	}
+	n, err := io.Copy(f, io.LimitReader(r, maxImageSize+1))
+	f.Close()
+	if err == nil && n > maxImageSize {
+		err = fmt.Errorf("image exceeds the size limit: %s", src)
+	}
+	if err != nil {
+		os.Remove(target)
+		return "", err
+	}
+	return name, nil
+}
+
+// copyAttachment copies a file into dirPath keeping its name
+func copyAttachment(src, dirPath string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		return "", err
+	}
+	name := filepath.Base(src)
+	out, err := os.Create(filepath.Join(dirPath, name))
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return name, err
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindImages(t *testing.T) {
+	content := `<p><img src="a.png"> <img alt="x" src='/static/b.jpg'></p>
+<img src="a.png"><img src="data:image/png;base64,xx">
+<img src="https://blog.example.com/api/v1/uploads/3/c.png">
+<img src="/api/v1/download/3/d.pdf"><a href="e.png">link</a>`
+	want := []string{"a.png", "/static/b.jpg"}
+	if got := findImages(content); !reflect.DeepEqual(got, want) {
+		t.Errorf("findImages = %q, want %q", got, want)
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	rewrites := map[string]string{
+		"files/a.png": "/api/v1/uploads/7/a.png",
+		"files/b.pdf": "/api/v1/uploads/7/b.pdf",
+	}
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`<img src="files/a.png">`, `<img src="/api/v1/uploads/7/a.png">`},
+		{`<a class="x" href='files/b.pdf'>b</a>`, `<a class="x" href="/api/v1/uploads/7/b.pdf">b</a>`},
+		{`<img src="other.png"> files/a.png`, `<img src="other.png"> files/a.png`},
+	}
+	for _, tt := range tests {
+		if got := rewriteLinks(tt.content, rewrites); got != tt.want {
+			t.Errorf("rewriteLinks(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestIsRemote(t *testing.T) {
+	for src, want := range map[string]bool{
+		"http://a.test/x.png":  true,
+		"https://a.test/x.png": true,
+		"//cdn.test/x.png":     true,
+		"/static/x.png":        false,
+		"x.png":                false,
+	} {
+		if got := isRemote(src); got != want {
+			t.Errorf("isRemote(%q) = %t, want %t", src, got, want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package importer
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxExtractSize limits the total size of an uploaded archive once extracted
+const maxExtractSize = 512 * 1024 * 1024
+
+// LoadPath parses a WXR file, a single Markdown file or a directory of
+// Markdown files (e.g. a Hugo or Jekyll repository)
+func LoadPath(path string) ([]Document, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".xml":
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return ParseWXR(f)
+		case ".md", ".markdown":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			doc, err := ParseMarkdown(filepath.Dir(path), filepath.Base(path), data)
+			if err != nil {
+				return nil, err
+			}
+			return []Document{doc}, nil
+		default:
+			return nil, fmt.Errorf("unsupported import file: %s", filepath.Base(path))
+		}
+	}
+
+	docs := []Document{}
+	err = filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := fi.Name()
+		if fi.IsDir() {
+			// Skip hidden directories, theme files and generated output
+			if p != path && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "themes" || name == "public" || name == "_site") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext != ".md" && ext != ".markdown" {
+			return nil
+		}
+		// Hugo section pages and repository docs are not posts
+		if name == "_index.md" || strings.EqualFold(name, "README.md") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		doc, err := ParseMarkdown(path, rel, data)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+		return nil
+	})
+	return docs, err
+}
+
+// ExtractZip extracts an uploaded archive into dest
+func ExtractZip(zipPath, dest string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var total int64
+	for _, f := range r.File {
+		target := filepath.Join(dest, f.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		total += int64(f.UncompressedSize64)
+		if total > maxExtractSize {
+			return fmt.Errorf("archive is too large")
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := extractFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, target string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, io.LimitReader(src, int64(f.UncompressedSize64)))
+	return err
+}
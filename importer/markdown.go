@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+	"gopkg.in/yaml.v3"
+)
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	// Posts written in the editor are stored as HTML, so raw HTML in
+	// Markdown (e.g. from our own export) is kept as is
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// Jekyll encodes the date in file names like 2021-03-04-my-post.md
+var jekyllFileName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+// splitFrontMatter separates YAML (---) or TOML (+++) front matter from the body
+func splitFrontMatter(data []byte) (map[string]interface{}, []byte, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	meta := map[string]interface{}{}
+
+	var delim string
+	switch {
+	case bytes.HasPrefix(data, []byte("---")):
+		delim = "---"
+	case bytes.HasPrefix(data, []byte("+++")):
+		delim = "+++"
+	default:
+		return meta, data, nil
+	}
+
+	rest := data[len(delim):]
+	end := bytes.Index(rest, []byte("\n"+delim))
+	if end < 0 {
+		return nil, nil, fmt.Errorf("unterminated front matter")
+	}
+	header := rest[:end]
+	body := rest[end+len(delim)+1:]
+	if i := bytes.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = nil
+	}
+
+	var err error
+	if delim == "---" {
+		err = yaml.Unmarshal(header, &meta)
+	} else {
+		_, err = toml.Decode(string(header), &meta)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+	return meta, body, nil
+}
+
+func metaString(meta map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case []interface{}:
+			if len(v) > 0 {
+				return fmt.Sprint(v[0])
+			}
+		case nil:
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+func metaList(meta map[string]interface{}, keys ...string) []string {
+	var values []string
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case string:
+			// Jekyll allows space separated lists
+			if strings.Contains(v, ",") {
+				values = append(values, strings.Split(v, ",")...)
+			} else {
+				values = append(values, strings.Fields(v)...)
+			}
+		case []interface{}:
+			for _, item := range v {
+				values = append(values, fmt.Sprint(item))
+			}
+		}
+	}
+	return cleanList(values)
+}
+
+func metaTime(meta map[string]interface{}, keys ...string) (time.Time, bool) {
+	for _, key := range keys {
+		switch v := meta[key].(type) {
+		case time.Time:
+			return v, true
+		case string:
+			if t, ok := parseDate(v); ok {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func metaBool(meta map[string]interface{}, key string) bool {
+	switch v := meta[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true" || v == "yes"
+	}
+	return false
+}
+
+// ParseMarkdown parses a Markdown file with YAML or TOML front matter as
+// written by Hugo or Jekyll. relPath is the path below root and is used as the
+// source ID unless the front matter sets one.
+func ParseMarkdown(root, relPath string, data []byte) (Document, error) {
+	meta, body, err := splitFrontMatter(data)
+	if err != nil {
+		return Document{}, fmt.Errorf("%s: %w", relPath, err)
+	}
+
+	// Our own export stores the editor HTML unchanged
+	var content bytes.Buffer
+	if metaString(meta, "format") == "html" {
+		content.Write(body)
+	} else if err := markdown.Convert(body, &content); err != nil {
+		return Document{}, fmt.Errorf("%s: %w", relPath, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(relPath), filepath.Ext(relPath))
+	if name == "index" {
+		// Hugo page bundle: the directory carries the name
+		name = filepath.Base(filepath.Dir(relPath))
+	}
+
+	doc := Document{
+		Source:      SourceMarkdown,
+		SourceID:    metaString(meta, "source_id", "id"),
+		Title:       metaString(meta, "title"),
+		Slug:        metaString(meta, "slug"),
+		Content:     content.String(),
+		Tags:        metaList(meta, "tags"),
+		Categories:  metaList(meta, "categories", "category"),
+		Author:      metaString(meta, "author", "authors"),
+		AuthorEmail: metaString(meta, "author_email", "email"),
+		Hidden:      metaBool(meta, "draft") || metaBool(meta, "hidden") || metaString(meta, "published") == "false",
+		File:        metaString(meta, "file"),
+		Dir:         filepath.Join(root, filepath.Dir(relPath)),
+		Root:        root,
+	}
+	if doc.SourceID == "" {
+		doc.SourceID = filepath.ToSlash(relPath)
+	}
+
+	if m := jekyllFileName.FindStringSubmatch(name); m != nil {
+		name = m[2]
+		if t, ok := parseDate(m[1]); ok {
+			doc.Date = t
+		}
+	}
+	if t, ok := metaTime(meta, "date", "publishDate"); ok {
+		doc.Date = t
+	}
+	if t, ok := metaTime(meta, "lastmod", "updated", "last_modified_at"); ok {
+		doc.Updated = &t
+	}
+	if doc.Title == "" {
+		doc.Title = name
+	}
+	if doc.Slug == "" {
+		doc.Slug = name
+	}
+	return doc, nil
+}
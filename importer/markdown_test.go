@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantMeta map[string]interface{}
+		wantBody string
+		wantErr  bool
+	}{
+		{"no front matter", "# Hello\n", map[string]interface{}{}, "# Hello\n", false},
+		{"yaml", "---\ntitle: Hello\n---\nbody\n", map[string]interface{}{"title": "Hello"}, "body\n", false},
+		{"toml", "+++\ntitle = \"Hello\"\n+++\nbody\n", map[string]interface{}{"title": "Hello"}, "body\n", false},
+		{"byte order mark", "\xef\xbb\xbf---\ntitle: Hello\n---\nbody", map[string]interface{}{"title": "Hello"}, "body", false},
+		{"no body", "---\ntitle: Hello\n---", map[string]interface{}{"title": "Hello"}, "", false},
+		{"unterminated", "---\ntitle: Hello\nbody\n", nil, "", true},
+		{"invalid yaml", "---\ntitle: [\n---\nbody\n", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta, body, err := splitFrontMatter([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(meta, tt.wantMeta) || string(body) != tt.wantBody {
+				t.Errorf("splitFrontMatter = %v, %q, want %v, %q", meta, body, tt.wantMeta, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMetaHelpers(t *testing.T) {
+	meta := map[string]interface{}{
+		"title":      "Hello",
+		"empty":      "",
+		"authors":    []interface{}{"Kim", "Lee"},
+		"weight":     3,
+		"tags":       []interface{}{"go", " web ", "go", ""},
+		"categories": "news, notes",
+		"keywords":   "one two",
+		"draft":      true,
+		"published":  "yes",
+		"date":       "2021-03-04 10:20",
+	}
+
+	strs := []struct {
+		keys []string
+		want string
+	}{
+		{[]string{"title"}, "Hello"},
+		{[]string{"empty", "title"}, "Hello"},
+		{[]string{"authors"}, "Kim"},
+		{[]string{"weight"}, "3"},
+		{[]string{"missing"}, ""},
+	}
+	for _, tt := range strs {
+		if got := metaString(meta, tt.keys...); got != tt.want {
+			t.Errorf("metaString(%q) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+
+	lists := []struct {
+		keys []string
+		want []string
+	}{
+		{[]string{"tags"}, []string{"go", "web"}},
+		{[]string{"categories"}, []string{"news", "notes"}},
+		{[]string{"keywords"}, []string{"one", "two"}},
+		{[]string{"missing"}, []string{}},
+	}
+	for _, tt := range lists {
+		if got := metaList(meta, tt.keys...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("metaList(%q) = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+
+	if !metaBool(meta, "draft") || !metaBool(meta, "published") || metaBool(meta, "title") {
+		t.Error("metaBool does not read bools and yes/true strings")
+	}
+	if got, ok := metaTime(meta, "missing", "date"); !ok || !got.Equal(time.Date(2021, 3, 4, 10, 20, 0, 0, time.UTC)) {
+		t.Errorf("metaTime = %v, %t", got, ok)
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	updated := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		relPath string
+		data    string
+		want    Document
+	}{
+		{
+			name:    "hugo page bundle",
+			relPath: "posts/my-post/index.md",
+			data:    "---\ntitle: My Post\ndate: 2021-03-04T10:20:00Z\nlastmod: 2021-04-01\ntags: [go, web]\ncategories: [notes]\nauthor: Kim\ndraft: true\n---\n# Hi\n\n**bold**\n",
+			want: Document{
+				Source:     SourceMarkdown,
+				SourceID:   "posts/my-post/index.md",
+				Title:      "My Post",
+				Slug:       "my-post",
+				Content:    "<h1>Hi</h1>\n<p><strong>bold</strong></p>\n",
+				Date:       time.Date(2021, 3, 4, 10, 20, 0, 0, time.UTC),
+				Updated:    &updated,
+				Tags:       []string{"go", "web"},
+				Categories: []string{"notes"},
+				Author:     "Kim",
+				Hidden:     true,
+				Dir:        filepath.Join("root", "posts/my-post"),
+				Root:       "root",
+			},
+		},
+		{
+			name:    "jekyll file name",
+			relPath: "_posts/2020-01-02-hello-world.md",
+			data:    "---\nlayout: post\ncategory: diary\npublished: false\n---\ntext\n",
+			want: Document{
+				Source:     SourceMarkdown,
+				SourceID:   "_posts/2020-01-02-hello-world.md",
+				Title:      "hello-world",
+				Slug:       "hello-world",
+				Content:    "<p>text</p>\n",
+				Date:       time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+				Tags:       []string{},
+				Categories: []string{"diary"},
+				Hidden:     true,
+				Dir:        filepath.Join("root", "_posts"),
+				Root:       "root",
+			},
+		},
+		{
+			name:    "our export",
+			relPath: "posts/7-hello/index.md",
+			data:    "---\nid: 7\ntitle: Hello\nslug: hello\nformat: html\nauthor_email: kim@example.com\nfile: a.pdf\n---\n<p>Raw <em>HTML</em></p>",
+			want: Document{
+				Source:      SourceMarkdown,
+				SourceID:    "7",
+				Title:       "Hello",
+				Slug:        "hello",
+				Content:     "<p>Raw <em>HTML</em></p>",
+				Tags:        []string{},
+				Categories:  []string{},
+				AuthorEmail: "kim@example.com",
+				File:        "a.pdf",
+				Dir:         filepath.Join("root", "posts/7-hello"),
+				Root:        "root",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMarkdown("root", tt.relPath, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMarkdown =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdownInvalid(t *testing.T) {
+	_, err := ParseMarkdown("root", "broken.md", []byte("---\ntitle: x\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "broken.md: ") {
+		t.Errorf("ParseMarkdown = %v, want an error naming the file", err)
+	}
+}
@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// wxrFile mirrors the parts of a WordPress eXtended RSS export we use
+type wxrFile struct {
+	Channel struct {
+		Authors []struct {
+			Login       string `xml:"author_login"`
+			Email       string `xml:"author_email"`
+			DisplayName string `xml:"author_display_name"`
+		} `xml:"author"`
+		Items []wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title      string `xml:"title"`
+	Link       string `xml:"link"`
+	Creator    string `xml:"creator"`
+	Content    string `xml:"encoded"`
+	PostID     string `xml:"post_id"`
+	PostDate   string `xml:"post_date_gmt"`
+	LocalDate  string `xml:"post_date"`
+	PostName   string `xml:"post_name"`
+	Status     string `xml:"status"`
+	PostType   string `xml:"post_type"`
+	Categories []struct {
+		Domain   string `xml:"domain,attr"`
+		Nicename string `xml:"nicename,attr"`
+		Name     string `xml:",chardata"`
+	} `xml:"category"`
+}
+
+var blockTag = regexp.MustCompile(`(?i)^\s*<(p|div|h[1-6]|ul|ol|li|blockquote|pre|table|figure|hr|!--)`)
+
+// autop wraps blank line separated text in paragraphs like WordPress does
+// when it renders post_content
+func autop(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, block := range strings.Split(content, "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		if blockTag.MatchString(block) {
+			b.WriteString(block)
+		} else {
+			b.WriteString("<p>" + strings.ReplaceAll(block, "\n", "<br>\n") + "</p>")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ParseWXR parses a WordPress WXR export and returns its posts. Pages,
+// attachments and other post types are skipped, as are trashed posts.
+func ParseWXR(r io.Reader) ([]Document, error) {
+	var file wxrFile
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid WXR file: %w", err)
+	}
+
+	emails := make(map[string]string)
+	names := make(map[string]string)
+	for _, author := range file.Channel.Authors {
+		emails[author.Login] = author.Email
+		names[author.Login] = author.DisplayName
+	}
+
+	docs := []Document{}
+	for _, item := range file.Channel.Items {
+		if item.PostType != "post" || item.Status == "trash" || item.Status == "auto-draft" {
+			continue
+		}
+
+		doc := Document{
+			Source:      SourceWordPress,
+			SourceID:    item.PostID,
+			Title:       strings.TrimSpace(item.Title),
+			Slug:        item.PostName,
+			Content:     autop(item.Content),
+			Author:      item.Creator,
+			AuthorEmail: emails[item.Creator],
+			Hidden:      item.Status != "publish",
+		}
+		if name := names[item.Creator]; name != "" {
+			doc.Author = name
+		}
+		if doc.SourceID == "" {
+			doc.SourceID = item.Link
+		}
+		if t, ok := parseDate(item.PostDate); ok && !strings.HasPrefix(item.PostDate, "0000") {
+			doc.Date = t
+		} else if t, ok := parseDate(item.LocalDate); ok {
+			doc.Date = t
+		}
+
+		var tags, categories []string
+		for _, category := range item.Categories {
+			switch category.Domain {
+			case "post_tag":
+				tags = append(tags, category.Name)
+			case "category":
+				if category.Nicename != "uncategorized" {
+					categories = append(categories, category.Name)
+				}
+			}
+		}
+		doc.Tags = cleanList(tags)
+		doc.Categories = cleanList(categories)
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testWXR = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"
+	xmlns:content="http://purl.org/rss/1.0/modules/content/"
+	xmlns:dc="http://purl.org/dc/elements/1.1/"
+	xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+	<wp:author>
+		<wp:author_login>kim</wp:author_login>
+		<wp:author_email>kim@example.com</wp:author_email>
+		<wp:author_display_name><![CDATA[Kim Minji]]></wp:author_display_name>
+	</wp:author>
+	<item>
+		<title> Hello &amp; welcome </title>
+		<link>https://old.example.com/?p=12</link>
+		<dc:creator>kim</dc:creator>
+		<content:encoded><![CDATA[First line
+second line
+
+<h2>Heading</h2>]]></content:encoded>
+		<wp:post_id>12</wp:post_id>
+		<wp:post_date>2020-05-06 18:30:00</wp:post_date>
+		<wp:post_date_gmt>2020-05-06 09:30:00</wp:post_date_gmt>
+		<wp:post_name>hello-welcome</wp:post_name>
+		<wp:status>publish</wp:status>
+		<wp:post_type>post</wp:post_type>
+		<category domain="category" nicename="uncategorized"><![CDATA[Uncategorized]]></category>
+		<category domain="category" nicename="notes"><![CDATA[Notes]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[go]]></category>
+		<category domain="post_tag" nicename="go"><![CDATA[go]]></category>
+	</item>
+	<item>
+		<title>Draft</title>
+		<link>https://old.example.com/?p=13</link>
+		<dc:creator>lee</dc:creator>
+		<content:encoded><![CDATA[]]></content:encoded>
+		<wp:post_id></wp:post_id>
+		<wp:post_date>2020-06-01 08:00:00</wp:post_date>
+		<wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+		<wp:status>draft</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+	<item>
+		<title>About</title>
+		<wp:post_id>2</wp:post_id>
+		<wp:status>publish</wp:status>
+		<wp:post_type>page</wp:post_type>
+	</item>
+	<item>
+		<title>Trashed</title>
+		<wp:post_id>3</wp:post_id>
+		<wp:status>trash</wp:status>
+		<wp:post_type>post</wp:post_type>
+	</item>
+</channel>
+</rss>`
+
+func TestParseWXR(t *testing.T) {
+	docs, err := ParseWXR(strings.NewReader(testWXR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Document{
+		{
+			Source:      SourceWordPress,
+			SourceID:    "12",
+			Title:       "Hello & welcome",
+			Slug:        "hello-welcome",
+			Content:     "<p>First line<br>\nsecond line</p>\n<h2>Heading</h2>\n",
+			Date:        time.Date(2020, 5, 6, 9, 30, 0, 0, time.UTC),
+			Tags:        []string{"go"},
+			Categories:  []string{"Notes"},
+			Author:      "Kim Minji",
+			AuthorEmail: "kim@example.com",
+		},
+		{
+			Source:     SourceWordPress,
+			SourceID:   "https://old.example.com/?p=13",
+			Title:      "Draft",
+			Date:       time.Date(2020, 6, 1, 8, 0, 0, 0, time.UTC),
+			Tags:       []string{},
+			Categories: []string{},
+			Author:     "lee",
+			Hidden:     true,
+		},
+	}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("ParseWXR =\n%+v\nwant\n%+v", docs, want)
+	}
+}
+
+func TestParseWXRInvalid(t *testing.T) {
+	if _, err := ParseWXR(strings.NewReader("not xml")); err == nil {
+		t.Error("ParseWXR accepted a file that is not XML")
+	}
+}
+
+func TestAutop(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"", ""},
+		{" \n\n ", ""},
+		{"one", "<p>one</p>\n"},
+		{"one\r\ntwo\r\n\r\nthree", "<p>one<br>\ntwo</p>\n<p>three</p>\n"},
+		{"<ul><li>x</li></ul>\n\n<!-- more -->\n\ntext", "<ul><li>x</li></ul>\n<!-- more -->\n<p>text</p>\n"},
+		{"<strong>inline</strong> text", "<p><strong>inline</strong> text</p>\n"},
+	}
+	for _, tt := range tests {
+		if got := autop(tt.content); got != tt.want {
+			t.Errorf("autop(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FromEnv returns the queue that sends mail through the SMTP server
+// configured by the environment, or nil when SMTP_HOST is not set:
+//
+//	SMTP_HOST          SMTP server; mail is disabled when empty
+//	SMTP_PORT          port (default 587)
+//	SMTP_USERNAME      user for PLAIN authentication, none when empty
+//	SMTP_PASSWORD      password for PLAIN authentication
+//	SMTP_FROM          sender address (default blog@localhost)
+//	SMTP_TLS           starttls (default), tls, or none for a local SMTP sink
+//	MAIL_LANGUAGE      template language of users without one (default ko)
+//	MAIL_MAX_ATTEMPTS  attempts before a message is given up (default 6)
+func FromEnv(db *gorm.DB) *Queue {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil
+	}
+	tlsMode := os.Getenv("SMTP_TLS")
+	if tlsMode != TLSImplicit && tlsMode != TLSNone {
+		tlsMode = TLSStartTLS
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "blog@localhost"
+	}
+	return &Queue{
+		DB: db,
+		Sender: &SMTP{
+			Host:     host,
+			Port:     envInt("SMTP_PORT", 587),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+			TLS:      tlsMode,
+		},
+		MaxAttempts: envInt("MAIL_MAX_ATTEMPTS", 6),
+		Backoff:     time.Minute,
+	}
+}
+
+// SendQueuedEvery sends the queued mail at every interval when SMTP is
+// configured. It blocks, so run it in its own goroutine.
+func SendQueuedEvery(db *gorm.DB, interval time.Duration) {
+	if q := FromEnv(db); q != nil {
+		q.Run(interval)
+	}
+}
+
+func envInt(name string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(name)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
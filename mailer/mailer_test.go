@@ -0,0 +1,220 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sinkMessage is an email received by smtpSink
+type sinkMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// smtpSink accepts mail on a local port like Mailpit and sends each message
+// to the returned channel
+func smtpSink(t *testing.T) (host string, port int, messages <-chan sinkMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan sinkMessage, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, ch)
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func serveSMTP(conn net.Conn, messages chan<- sinkMessage) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 sink ESMTP")
+	var msg sinkMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			tp.PrintfLine("250 sink")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			msg.From = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			tp.PrintfLine("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.To = append(msg.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			tp.PrintfLine("250 OK")
+		case cmd == "DATA":
+			tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = string(data)
+			tp.PrintfLine("250 OK")
+			messages <- msg
+			msg = sinkMessage{}
+		case cmd == "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// receive waits for the next message of the sink
+func receive(t *testing.T, messages <-chan sinkMessage) sinkMessage {
+	t.Helper()
+	select {
+	case msg := <-messages:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return sinkMessage{}
+	}
+}
+
+// parts decodes the bodies of a multipart/alternative email by content type
+func parts(t *testing.T, msg *mail.Message) map[string]string {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", msg.Header.Get("Content-Type"), err)
+	}
+	bodies := make(map[string]string)
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return bodies
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		// multipart.Reader decodes the quoted-printable bodies
+		body, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies[contentType] = string(body)
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	host, port, messages := smtpSink(t)
+	sender := &SMTP{
+		Host: host,
+		Port: port,
+		From: "Blog <blog@example.com>",
+		TLS:  TLSNone,
+	}
+
+	m, err := Render(TemplateComment, "en", CommentNotice{
+		RecipientName:  "Kim",
+		ActorName:      "Lee",
+		PostTitle:      "Hello, 세계",
+		Excerpt:        "Nice post",
+		URL:            "https://blog.example.com/post/1#comment-2",
+		UnsubscribeURL: "https://blog.example.com/api/v1/notifications/unsubscribe?token=abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.To = "Kim <kim@example.com>"
+	m.Unsubscribe = "https://blog.example.com/api/v1/notifications/unsubscribe?token=abc"
+	if err := sender.Send(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+
+	got := receive(t, messages)
+	if got.From != "blog@example.com" {
+		t.Errorf("MAIL FROM = %q, want blog@example.com", got.From)
+	}
+	if len(got.To) != 1 || got.To[0] != "kim@example.com" {
+		t.Errorf("RCPT TO = %q, want [kim@example.com]", got.To)
+	}
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(got.Data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[Hello, 세계] New comment on your post"; subject != want {
+		t.Errorf("Subject = %q, want %q", subject, want)
+	}
+	if got, want := msg.Header.Get("List-Unsubscribe"), "<"+m.Unsubscribe+">"; got != want {
+		t.Errorf("List-Unsubscribe = %q, want %q", got, want)
+	}
+	if got := msg.Header.Get("List-Unsubscribe-Post"); got != "List-Unsubscribe=One-Click" {
+		t.Errorf("List-Unsubscribe-Post = %q", got)
+	}
+
+	bodies := parts(t, msg)
+	if !strings.Contains(bodies["text/plain"], "Lee commented on \"Hello, 세계\".") {
+		t.Errorf("text body = %q", bodies["text/plain"])
+	}
+	if !strings.Contains(bodies["text/html"], "https://blog.example.com/post/1#comment-2") {
+		t.Errorf("html body = %q", bodies["text/html"])
+	}
+}
+
+func TestSMTPSendWithoutUnsubscribe(t *testing.T) {
+	host, port, messages := smtpSink(t)
+	sender := &SMTP{Host: host, Port: port, From: "blog@example.com", TLS: TLSNone}
+
+	err := sender.Send(context.Background(), Message{
+		To:      "kim@example.com",
+		Subject: "Test",
+		Text:    "plain",
+		HTML:    "<p>html</p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(receive(t, messages).Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Header.Get("List-Unsubscribe"); got != "" {
+		t.Errorf("List-Unsubscribe = %q, want none", got)
+	}
+	bodies := parts(t, msg)
+	if bodies["text/plain"] != "plain" || bodies["text/html"] != "<p>html</p>" {
+		t.Errorf("bodies = %q", bodies)
+	}
+}
+
+func TestSMTPSendInvalidAddress(t *testing.T) {
+	sender := &SMTP{Host: "127.0.0.1", Port: 1, From: "blog@example.com", TLS: TLSNone}
+	err := sender.Send(context.Background(), Message{To: "not an address", Subject: "Test"})
+	if err == nil || !strings.Contains(err.Error(), "invalid to address") {
+		t.Errorf("Send = %v, want an invalid to address error", err)
+	}
+}
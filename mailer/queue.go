@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// queueBatch is how many messages are sent per run
+	queueBatch = 20
+	// sendLease keeps other workers from sending a message that is being sent
+	sendLease = 10 * time.Minute
+)
+
+// Queue keeps outgoing messages in the database and sends them, retrying
+// failed messages with exponential backoff
+type Queue struct {
+	DB          *gorm.DB
+	Sender      Sender
+	MaxAttempts int
+	// Backoff is the delay after the first failed attempt. It doubles after
+	// every further failure.
+	Backoff time.Duration
+}
+
+// Enqueue stores a message to be sent by the next run
+func (q *Queue) Enqueue(userID *uint, m Message) error {
+	return q.DB.Create(&models.EmailMessage{
+		UserID:        userID,
+		ToAddress:     m.To,
+		Subject:       m.Subject,
+		TextBody:      m.Text,
+		HTMLBody:      m.HTML,
+		Unsubscribe:   m.Unsubscribe,
+		Status:        models.EmailQueued,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// SendDue sends the queued messages whose next attempt is due and returns
+// how many were sent
+func (q *Queue) SendDue(ctx context.Context) (int, error) {
+	var due []models.EmailMessage
+	err := q.DB.Where("status = ? AND next_attempt_at <= ?", models.EmailQueued, time.Now()).
+		Order("next_attempt_at, id").Limit(queueBatch).Find(&due).Error
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, email := range due {
+		// 여러 서버가 같은 메일을 보내지 않도록 먼저 차지
+		claim := q.DB.Model(&models.EmailMessage{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", email.ID, models.EmailQueued, email.NextAttemptAt).
+			UpdateColumn("next_attempt_at", time.Now().Add(sendLease))
+		if claim.Error != nil {
+			return sent, claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			continue
+		}
+
+		sendErr := q.Sender.Send(ctx, Message{
+			To:          email.ToAddress,
+			Subject:     email.Subject,
+			Text:        email.TextBody,
+			HTML:        email.HTMLBody,
+			Unsubscribe: email.Unsubscribe,
+		})
+		if err := q.DB.Model(&email).UpdateColumns(q.result(email, sendErr)).Error; err != nil {
+			return sent, err
+		}
+		if sendErr == nil {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// result returns the columns that record the outcome of an attempt
+func (q *Queue) result(email models.EmailMessage, err error) map[string]interface{} {
+	now := time.Now()
+	if err == nil {
+		return map[string]interface{}{
+			"status":     models.EmailSent,
+			"attempts":   email.Attempts + 1,
+			"sent_at":    now,
+			"last_error": "",
+		}
+	}
+
+	attempts := email.Attempts + 1
+	message := err.Error()
+	if len(message) > 500 {
+		message = message[:500]
+	}
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"last_error":      message,
+		"next_attempt_at": now.Add(q.Backoff << (attempts - 1)),
+	}
+	if attempts >= q.MaxAttempts {
+		log.Printf("Giving up on email %d to %s: %v", email.ID, email.ToAddress, err)
+		updates["status"] = models.EmailFailed
+	}
+	return updates
+}
+
+// Run sends due messages at every interval. It blocks, so run it in its own
+// goroutine.
+func (q *Queue) Run(interval time.Duration) {
+	for ; ; time.Sleep(interval) {
+		n, err := q.SendDue(context.Background())
+		if err != nil {
+			log.Printf("Failed to send queued emails: %v", err)
+		}
+		if n > 0 {
+			log.Printf("Sent %d emails", n)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+)
+
+func TestQueueResult(t *testing.T) {
+	q := &Queue{MaxAttempts: 3, Backoff: time.Minute}
+
+	sent := q.result(models.EmailMessage{Attempts: 1}, nil)
+	if sent["status"] != models.EmailSent || sent["attempts"] != 2 || sent["last_error"] != "" {
+		t.Errorf("sent = %v", sent)
+	}
+
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+		failed   bool
+	}{
+		{0, time.Minute, false},
+		{1, 2 * time.Minute, false},
+		// 마지막 시도에 실패하면 포기
+		{2, 4 * time.Minute, true},
+	}
+	for _, tt := range tests {
+		before := time.Now()
+		updates := q.result(models.EmailMessage{Attempts: tt.attempts}, errors.New(strings.Repeat("x", 600)))
+		if updates["attempts"] != tt.attempts+1 {
+			t.Errorf("attempt %d: attempts = %v", tt.attempts+1, updates["attempts"])
+		}
+		if len(updates["last_error"].(string)) != 500 {
+			t.Errorf("attempt %d: last_error is %d bytes, want 500", tt.attempts+1, len(updates["last_error"].(string)))
+		}
+		next := updates["next_attempt_at"].(time.Time)
+		if delay := next.Sub(before); delay < tt.backoff || delay > tt.backoff+time.Second {
+			t.Errorf("attempt %d: retried after %v, want %v", tt.attempts+1, delay, tt.backoff)
+		}
+		if _, failed := updates["status"]; failed != tt.failed || (failed && updates["status"] != models.EmailFailed) {
+			t.Errorf("attempt %d: status = %v, want failed %t", tt.attempts+1, updates["status"], tt.failed)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", "")
+	if q := FromEnv(nil); q != nil {
+		t.Errorf("FromEnv without SMTP_HOST = %+v, want nil", q)
+	}
+
+	t.Setenv("SMTP_HOST", "mail.example.com")
+	t.Setenv("SMTP_PORT", "x")
+	t.Setenv("SMTP_TLS", "ssl")
+	t.Setenv("SMTP_FROM", "")
+	t.Setenv("MAIL_MAX_ATTEMPTS", "0")
+	q := FromEnv(nil)
+	smtp := q.Sender.(*SMTP)
+	if smtp.Host != "mail.example.com" || smtp.Port != 587 || smtp.TLS != TLSStartTLS || smtp.From != "blog@localhost" {
+		t.Errorf("SMTP = %+v, want the defaults", smtp)
+	}
+	if q.MaxAttempts != 6 {
+		t.Errorf("MaxAttempts = %d, want 6", q.MaxAttempts)
+	}
+
+	t.Setenv("SMTP_PORT", "1025")
+	t.Setenv("SMTP_TLS", TLSNone)
+	if smtp := FromEnv(nil).Sender.(*SMTP); smtp.Port != 1025 || smtp.TLS != TLSNone {
+		t.Errorf("SMTP = %+v, want port 1025 without TLS", smtp)
+	}
+}
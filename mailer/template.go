@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.New("").Funcs(texttemplate.FuncMap{
+		// oneline keeps a value with line breaks, such as a post title, from
+		// ending the subject line early
+		"oneline": func(s string) string { return strings.Join(strings.Fields(s), " ") },
+	}).ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+)
+
+// Template names
+const (
+	TemplateComment = "comment" // a new comment on the recipient's post
+	TemplateReply   = "reply"   // a reply to the recipient's comment
+)
+
+// CommentNotice is the data of the comment and reply templates
+type CommentNotice struct {
+	RecipientName  string
+	ActorName      string // empty for anonymous commenters
+	PostTitle      string
+	Excerpt        string
+	URL            string
+	UnsubscribeURL string
+}
+
+// Language returns lang if the templates are written in it, and otherwise
+// MAIL_LANGUAGE or Korean
+func Language(lang string) string {
+	for _, l := range []string{lang, os.Getenv("MAIL_LANGUAGE")} {
+		if l == "ko" || l == "en" {
+			return l
+		}
+	}
+	return "ko"
+}
+
+// Render fills the templates <name>.<lang>.txt and <name>.<lang>.html. The
+// first line of the text template is the subject; values in it go through
+// oneline.
+func Render(name, lang string, data interface{}) (Message, error) {
+	lang = Language(lang)
+	var text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&text, name+"."+lang+".txt", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+"."+lang+".html", data); err != nil {
+		return Message{}, err
+	}
+
+	subject, body, ok := strings.Cut(text.String(), "\n")
+	if !ok || strings.TrimSpace(subject) == "" {
+		return Message{}, fmt.Errorf("template %s.%s.txt has no subject line", name, lang)
+	}
+	return Message{
+		// 줄바꿈이 섞인 제목으로 헤더가 깨지지 않도록 정리
+		Subject: strings.Join(strings.Fields(subject), " "),
+		Text:    body,
+		HTML:    html.String(),
+	}, nil
+}
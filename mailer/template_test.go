@@ -0,0 +1,83 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		lang, env string
+		want      string
+	}{
+		{"en", "", "en"},
+		{"ko", "en", "ko"},
+		{"", "", "ko"},
+		{"ja", "", "ko"},
+		{"ja", "en", "en"},
+		{"", "fr", "ko"},
+	}
+	for _, tt := range tests {
+		t.Setenv("MAIL_LANGUAGE", tt.env)
+		if got := Language(tt.lang); got != tt.want {
+			t.Errorf("Language(%q) with MAIL_LANGUAGE=%q = %q, want %q", tt.lang, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Setenv("MAIL_LANGUAGE", "")
+	notice := CommentNotice{
+		RecipientName:  "Kim",
+		ActorName:      "Lee",
+		PostTitle:      "Hello\nWorld",
+		Excerpt:        "<b>great</b>",
+		URL:            "https://blog.example.com/post/1#comment-2",
+		UnsubscribeURL: "https://blog.example.com/unsubscribe",
+	}
+	anonymous := notice
+	anonymous.ActorName = ""
+
+	tests := []struct {
+		name, template, lang string
+		data                 CommentNotice
+		subject              string
+		text                 string
+	}{
+		{"comment en", TemplateComment, "en", notice, "[Hello World] New comment on your post", "Lee commented"},
+		{"comment ko", TemplateComment, "ko", notice, "[Hello World] 새 댓글이 달렸습니다", "Lee님이"},
+		{"anonymous ko", TemplateComment, "ko", anonymous, "[Hello World] 새 댓글이 달렸습니다", "익명의 방문자가"},
+		{"reply en", TemplateReply, "en", notice, "[Hello World] New reply to your comment", "Hi Kim,"},
+		// 지원하지 않는 언어는 한국어로
+		{"reply fallback", TemplateReply, "ja", notice, "[Hello World] 내 댓글에 답글이 달렸습니다", "Kim님, 안녕하세요."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Render(tt.template, tt.lang, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", m.Subject, tt.subject)
+			}
+			if !strings.Contains(m.Text, tt.text) {
+				t.Errorf("Text = %q, want it to contain %q", m.Text, tt.text)
+			}
+			if !strings.Contains(m.Text, "<b>great</b>") {
+				t.Errorf("Text = %q, want the excerpt as is", m.Text)
+			}
+			if strings.Contains(m.HTML, "<b>great</b>") || !strings.Contains(m.HTML, "&lt;b&gt;great&lt;/b&gt;") {
+				t.Errorf("HTML = %q, want the excerpt escaped", m.HTML)
+			}
+			if !strings.Contains(m.HTML, tt.data.UnsubscribeURL) {
+				t.Errorf("HTML = %q, want the unsubscribe link", m.HTML)
+			}
+		})
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Render("digest", "en", CommentNotice{}); err == nil {
+		t.Error("Render of an unknown template succeeded")
+	}
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 
 	"github.com/bloomingFlower/blog-backend/database"
+	"github.com/bloomingFlower/blog-backend/mailer"
 	"github.com/bloomingFlower/blog-backend/routes"
+	"github.com/bloomingFlower/blog-backend/util"
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
 )
@@ -21,14 +23,19 @@ func main() {
 		log.Printf("Warning: Error loading .env file: %v", err)
 	}
 	port := os.Getenv("PORT")
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		// 기본 한도보다 큰 본문은 스트림으로 받아 middleware.LimitBody에서 검사
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
 
 	// CORS 미들웨어 설정
 	app.Use(cors.New(cors.Config{
 		AllowCredentials: true,
 		AllowOrigins:     "http://localhost:8080, https://blog.yourrubber.duckdns.org:443",
 		AllowMethods:     "POST, GET, OPTIONS, PUT, DELETE",
-		AllowHeaders:     "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization",
+		AllowHeaders:     "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, If-Match, X-Share-Token, X-Comment-Token",
+		ExposeHeaders:    "ETag",
 	}))
 
 	// 전역 속도 제한 미들웨어 설정
@@ -55,6 +62,15 @@ func main() {
 
 	routes.Setup(app)
 
+	// 휴지통 보관 기간이 지난 포스트 영구 삭제
+	go util.PurgeTrashEvery(database.DB, "uploads", time.Hour)
+
+	// 보관 기간이 지난 댓글 작성자 IP 삭제
+	go util.EraseIPAddressesEvery(database.DB, time.Hour)
+
+	// 알림 메일 발송 대기열 처리
+	go mailer.SendQueuedEvery(database.DB, time.Minute)
+
 	// Log warning if unable to start the server
 	if err := app.Listen(":" + port); err != nil {
 		log.Printf("Warning: Unable to start server: %v", err)
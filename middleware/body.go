@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LimitBody rejects request bodies larger than limit bytes, or than the size
+// given in large for the request path. The app must stream request bodies
+// (fiber.Config.StreamRequestBody) so that bodies over the default limit are
+// not read into memory before this check.
+func LimitBody(limit int, large map[string]int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		max := limit
+		if n, ok := large[c.Path()]; ok {
+			max = n
+		}
+
+		req := c.Request()
+		length := req.Header.ContentLength()
+		if length > max {
+			return bodyTooLarge(c)
+		}
+		// 길이를 모르는 본문은 한도까지만 읽음
+		if length < 0 && req.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(max)+1))
+			if err != nil {
+				return err
+			}
+			if len(body) > max {
+				return bodyTooLarge(c)
+			}
+			req.SetBody(body)
+		}
+		return c.Next()
+	}
+}
+
+func bodyTooLarge(c *fiber.Ctx) error {
+	// 읽지 않은 본문이 남아 있으므로 연결을 닫음
+	c.Context().SetConnectionClose()
+	c.Status(fiber.StatusRequestEntityTooLarge)
+	return c.JSON(fiber.Map{
+		"message": "Request body is too large",
+	})
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestLimitBody(t *testing.T) {
+	app := fiber.New(fiber.Config{
+		BodyLimit:         64 * 1024,
+		StreamRequestBody: true,
+	})
+	app.Use(LimitBody(1000, map[string]int{"/big": 16 * 1024}))
+	echo := func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	}
+	app.Post("/small", echo)
+	app.Post("/big", echo)
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"small body", "/small", 500, false, fiber.StatusOK},
+		{"at the limit", "/small", 1000, false, fiber.StatusOK},
+		{"over the limit", "/small", 1001, false, fiber.StatusRequestEntityTooLarge},
+		{"chunked over the limit", "/small", 5000, true, fiber.StatusRequestEntityTooLarge},
+		{"chunked below the limit", "/small", 800, true, fiber.StatusOK},
+		{"large path", "/big", 10 * 1024, false, fiber.StatusOK},
+		{"large path chunked", "/big", 10 * 1024, true, fiber.StatusOK},
+		{"over the large limit", "/big", 20 * 1024, false, fiber.StatusRequestEntityTooLarge},
+		{"chunked over the large limit", "/big", 20 * 1024, true, fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = bytes.NewReader(bytes.Repeat([]byte("x"), tt.size))
+			if tt.chunked {
+				// 길이를 알 수 없는 본문은 chunked로 전송됨
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, body)
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			got, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", resp.StatusCode, tt.want, got)
+			}
+			if tt.want == fiber.StatusOK && string(got) != strconv.Itoa(tt.size) {
+				t.Errorf("handler read %s bytes, want %d", got, tt.size)
+			}
+			if tt.want != fiber.StatusOK && !strings.Contains(string(got), "Request body is too large") {
+				t.Errorf("body = %s", got)
+			}
+		})
+	}
+}
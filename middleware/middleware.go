@@ -25,7 +25,60 @@ func IsAuthenticate(c *fiber.Ctx) error {
 			"message": "User not found",
 		})
 	}
+	if user.Blocked {
+		c.Status(fiber.StatusForbidden)
+		return c.JSON(fiber.Map{
+			"message": "User is blocked",
+		})
+	}
 	c.Locals("userID", id) // Set the user ID in locals
+	c.Locals("user", &user)
 
 	return c.Next()
 }
+
+// OptionalAuthenticate sets the user like IsAuthenticate when a valid JWT is
+// present and lets anonymous requests through. Blocked users are rejected.
+func OptionalAuthenticate(c *fiber.Ctx) error {
+	id, err := util.ParseJwt(c.Cookies("jwt"))
+	if err != nil || id == "" {
+		return c.Next()
+	}
+
+	var user models.User
+	database.DB.Where("id = ?", id).First(&user)
+	if user.ID == 0 {
+		return c.Next()
+	}
+	if user.Blocked {
+		c.Status(fiber.StatusForbidden)
+		return c.JSON(fiber.Map{
+			"message": "User is blocked",
+		})
+	}
+	c.Locals("userID", id)
+	c.Locals("user", &user)
+
+	return c.Next()
+}
+
+// RequirePermission rejects users whose role does not grant p. It must run
+// after IsAuthenticate.
+func RequirePermission(p models.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !CurrentUser(c).Can(p) {
+			c.Status(fiber.StatusForbidden)
+			return c.JSON(fiber.Map{
+				"message": "You don't have permission to perform this action",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// CurrentUser returns the user set by IsAuthenticate or OptionalAuthenticate,
+// or nil for anonymous requests
+func CurrentUser(c *fiber.Ctx) *models.User {
+	user, _ := c.Locals("user").(*models.User)
+	return user
+}
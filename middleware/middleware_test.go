@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name string
+		user *models.User
+		want int
+	}{
+		{"anonymous", nil, fiber.StatusForbidden},
+		{"commenter", &models.User{ID: 1, Role: models.RoleCommenter}, fiber.StatusForbidden},
+		{"blocked admin", &models.User{ID: 1, Role: models.RoleAdmin, Blocked: true}, fiber.StatusForbidden},
+		{"admin", &models.User{ID: 1, Role: models.RoleAdmin}, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				if tt.user != nil {
+					c.Locals("user", tt.user)
+				}
+				return c.Next()
+			})
+			app.Get("/", RequirePermission(models.PermImportExport), func(c *fiber.Ctx) error {
+				return c.SendString("ok")
+			})
+			resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentUser(t *testing.T) {
+	app := fiber.New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+	if CurrentUser(c) != nil {
+		t.Error("CurrentUser of an anonymous request is not nil")
+	}
+	user := &models.User{ID: 3}
+	c.Locals("user", user)
+	if CurrentUser(c) != user {
+		t.Error("CurrentUser does not return the user in locals")
+	}
+}
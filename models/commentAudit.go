@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Comment audit actions
+const (
+	CommentAuditDelete = "delete"
+	CommentAuditEdit   = "edit"
+)
+
+// How the actor of an audited action was allowed to act
+const (
+	CommentViaAuthor     = "author"      // the commenter
+	CommentViaToken      = "token"       // an anonymous commenter with the delete token
+	CommentViaPostAuthor = "post_author" // someone who manages the post
+	CommentViaModerator  = "moderator"
+)
+
+// CommentAudit records who changed or deleted a comment
+type CommentAudit struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"index"`
+	PostID    uint      `json:"post_id" gorm:"index"`
+	Action    string    `json:"action" gorm:"size:20"`
+	ActorID   *uint     `json:"actor_id"`
+	Actor     *User     `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	Via       string    `json:"via" gorm:"size:20"`
+	IPAddress string    `json:"-"` // 예전 기록만 가지고 있음
+	IPHash    string    `json:"ip_hash" gorm:"size:64;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
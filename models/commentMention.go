@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// CommentMention records a user mentioned with @username in a comment
+type CommentMention struct {
+	CommentID  uint       `json:"-" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"primaryKey;index"`
+	Username   string     `json:"username" gorm:"size:32"` // 댓글에 쓰인 그대로의 사용자 이름
+	NotifiedAt *time.Time `json:"-"`                       // 댓글이 승인되어 알림을 보낸 시각
+}
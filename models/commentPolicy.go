@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// CommentPolicy decides which new comments are held for moderation. The
+// policy with PostID 0 applies to every post without a policy of its own.
+type CommentPolicy struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	PostID        uint      `json:"post_id" gorm:"uniqueIndex"`
+	HoldAll       bool      `json:"hold_all"`
+	HoldAnonymous bool      `json:"hold_anonymous"` // comments written without logging in
+	HoldLinks     bool      `json:"hold_links"`     // comments containing a link
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
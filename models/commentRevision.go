@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// CommentRevision keeps the content a comment had before an edit
+type CommentRevision struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"index"`
+	Content   string    `json:"content"`
+	EditedBy  *uint     `json:"edited_by"` // nil when an anonymous commenter edited with the token
+	Via       string    `json:"via" gorm:"size:20"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -1,34 +1,92 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// Moderation statuses of a comment. Only approved comments are public.
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentSpam     = "spam"
+	CommentRejected = "rejected"
+)
+
+// ValidCommentStatus reports whether status is one of the moderation statuses
+func ValidCommentStatus(status string) bool {
+	switch status {
+	case CommentPending, CommentApproved, CommentSpam, CommentRejected:
+		return true
+	}
+	return false
+}
+
 // Comment represents a comment on a post
 type Comment struct {
 	gorm.Model
-	PostID    uint      `json:"post_id"`
-	UserID    *uint     `json:"user_id"`
-	ParentID  *uint     `json:"parent_id"`
-	Content   string    `json:"content"`
-	Votes     []Vote    `json:"votes" gorm:"foreignKey:CommentID"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	User      User      `json:"user" gorm:"foreignKey:UserID"`
-	Parent    *Comment  `json:"parent" gorm:"foreignKey:ParentID"`
-	Children  []Comment `json:"children" gorm:"foreignKey:ParentID"`
-	IPAddress string    `json:"ip_address"`
+	PostID      uint             `json:"post_id"`
+	UserID      *uint            `json:"user_id"`
+	ParentID    *uint            `json:"parent_id"`
+	Content     string           `json:"content"`
+	Status      string           `json:"status" gorm:"size:20;not null;default:approved;index"`
+	HoldReason  string           `json:"hold_reason,omitempty"` // 검토 대기 중인 이유
+	SpamScore   float64          `json:"spam_score,omitempty"`
+	TrainedAs   string           `json:"-" gorm:"size:10"` // 스팸 필터에 학습시킨 판정 (spam, ham)
+	DeleteToken string           `json:"-" gorm:"size:64"` // 익명 댓글 삭제·수정 토큰의 해시
+	Edited      bool             `json:"edited"`
+	EditedAt    *time.Time       `json:"edited_at"`
+	ModeratedBy *uint            `json:"moderated_by,omitempty"`
+	ModeratedAt *time.Time       `json:"moderated_at,omitempty"`
+	NotifiedAt  *time.Time       `json:"-"` // 승인되어 글쓴이와 부모 댓글 작성자에게 알린 시각
+	Votes       []Vote           `json:"-" gorm:"foreignKey:CommentID"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	User        User             `json:"user" gorm:"foreignKey:UserID"`
+	Parent      *Comment         `json:"parent" gorm:"foreignKey:ParentID"`
+	Children    []Comment        `json:"children" gorm:"foreignKey:ParentID"`
+	Mentions    []CommentMention `json:"mentions,omitempty" gorm:"foreignKey:CommentID"`
+	Deleted     bool             `json:"deleted,omitempty" gorm:"-"`      // 답글이 남아 있어 "[deleted]"로 표시되는 삭제된 댓글
+	MoreReplies int              `json:"more_replies,omitempty" gorm:"-"` // 최대 깊이 아래에 더 있는 답글 수
+	Reactions   []ReactionCount  `json:"reactions" gorm:"-"`              // 이모지별 반응 수
+	MyReactions []string         `json:"my_reactions" gorm:"-"`           // 요청한 사용자가 남긴 이모지
+	IPAddress   string           `json:"-"`                               // 예전 댓글만 가지고 있고 보관 기간이 지나면 지워짐
+	IPHash      string           `json:"-" gorm:"size:64;index"`          // 중복·속도 제한과 검토에 쓰는 IP 해시
+}
+
+// ReactionCount is how many votes a comment has with one emoji
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
 }
 
 // Vote represents a vote (emoji reaction) on a comment
 type Vote struct {
 	gorm.Model
-	CommentID uint      `json:"comment_id"`
+	CommentID uint      `json:"comment_id" gorm:"uniqueIndex:idx_vote_voter"`
 	UserID    *uint     `json:"user_id"`
-	Emoji     string    `json:"emoji"`
+	Voter     *string   `json:"-" gorm:"size:80;uniqueIndex:idx_vote_voter"` // 같은 사람이 같은 이모지를 두 번 달지 못하게 하는 키
+	Emoji     string    `json:"emoji" gorm:"size:64;uniqueIndex:idx_vote_voter"`
 	CreatedAt time.Time `json:"created_at"`
 	User      User      `json:"user" gorm:"foreignKey:UserID"`
-	IPAddress string    `json:"ip_address"`
+	IPAddress string    `json:"-"` // 예전 익명 반응만 가지고 있음
+	IPHash    string    `json:"-" gorm:"size:64;index"`
+}
+
+// SetVoter sets the key of the unique index from the user or, for anonymous
+// votes, the IP hash
+func (v *Vote) SetVoter() {
+	v.Voter = nil
+	var voter string
+	switch {
+	case v.UserID != nil:
+		voter = "user:" + strconv.FormatUint(uint64(*v.UserID), 10)
+	case v.IPHash != "":
+		voter = "ip:" + v.IPHash
+	default:
+		return
+	}
+	v.Voter = &voter
 }
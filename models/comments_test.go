@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidCommentStatus(t *testing.T) {
+	for status, want := range map[string]bool{
+		CommentPending:  true,
+		CommentApproved: true,
+		CommentSpam:     true,
+		CommentRejected: true,
+		"":              false,
+		"deleted":       false,
+		"Approved":      false,
+	} {
+		if got := ValidCommentStatus(status); got != want {
+			t.Errorf("ValidCommentStatus(%q) = %t, want %t", status, got, want)
+		}
+	}
+}
+
+func TestVoteSetVoter(t *testing.T) {
+	userID := uint(7)
+	old := "ip:old"
+	tests := []struct {
+		name string
+		vote Vote
+		want string
+	}{
+		{"user", Vote{UserID: &userID, IPHash: "abc"}, "user:7"},
+		{"anonymous", Vote{IPHash: "abc"}, "ip:abc"},
+		{"unknown", Vote{Voter: &old}, ""},
+	}
+	for _, tt := range tests {
+		tt.vote.SetVoter()
+		got := ""
+		if tt.vote.Voter != nil {
+			got = *tt.vote.Voter
+		}
+		if got != tt.want {
+			t.Errorf("%s: Voter = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCommentJSONHidesIP(t *testing.T) {
+	comment := Comment{IPAddress: "203.0.113.7", IPHash: "abc", Votes: []Vote{{IPAddress: "203.0.113.8", IPHash: "def"}}}
+	b, err := json.Marshal(comment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"203.0.113", "ip_", "abc", "def"} {
+		if strings.Contains(string(b), s) {
+			t.Errorf("comment JSON %s contains %q", b, s)
+		}
+	}
+}
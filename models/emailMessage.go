@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Statuses of a queued email
+const (
+	EmailQueued = "queued"
+	EmailSent   = "sent"
+	EmailFailed = "failed" // gave up after the last attempt
+)
+
+// EmailMessage is an outgoing email waiting in the send queue
+type EmailMessage struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	UserID        *uint      `json:"user_id" gorm:"index"`
+	ToAddress     string     `json:"to_address"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"-" gorm:"type:text"`
+	HTMLBody      string     `json:"-" gorm:"type:mediumtext"`
+	Unsubscribe   string     `json:"-" gorm:"size:500"` // 원클릭 수신 거부 URL
+	Status        string     `json:"status" gorm:"size:10;index:idx_email_due"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index:idx_email_due"`
+	LastError     string     `json:"last_error" gorm:"size:500"`
+	SentAt        *time.Time `json:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
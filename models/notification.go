@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Notification types
+const (
+	NotificationMention = "mention" // someone mentioned the user in a comment
+	NotificationComment = "comment" // someone commented on the user's post
+	NotificationReply   = "reply"   // someone replied to the user's comment
+)
+
+// Notification tells a user about activity that concerns them
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	Type      string     `json:"type" gorm:"size:20"`
+	ActorID   *uint      `json:"actor_id"`
+	Actor     *User      `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	PostID    uint       `json:"post_id" gorm:"index"`
+	CommentID uint       `json:"comment_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index"`
+}
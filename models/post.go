@@ -1,19 +1,50 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
 
 type Post struct {
-	ID           uint       `json:"id" gorm:"primaryKey"`
-	Title        string     `json:"title"`
-	Content      string     `json:"content"`
-	File         string     `json:"file"`
-	Tags         string     `json:"tags"`
-	Category     string     `json:"category"`
-	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt    *time.Time `json:"updated_at" gorm:"autoUpdateTime:false"`
-	DeletedAt    *time.Time `gorm:"index" json:"deleted_at"`
-	Hidden       bool       `json:"hidden"`
-	UserID       uint       `json:"user_id"`
-	User         User       `json:"user" gorm:"foreignKey:UserID"`
-	CommentCount int        `json:"comment_count" gorm:"-"`
+	ID           uint             `json:"id" gorm:"primaryKey"`
+	Title        string           `json:"title"`
+	Slug         string           `json:"slug" gorm:"size:191;uniqueIndex:idx_post_slug;default:null"` // 없으면 NULL
+	Lang         string           `json:"lang" gorm:"size:10;default:ko"`                              // 원문 언어
+	Content      string           `json:"content"`
+	File         string           `json:"file"` // 첫 번째 첨부파일, Attachments 이전 클라이언트 호환용
+	Tags         string           `json:"tags"`
+	Category     string           `json:"category"`
+	CreatedAt    time.Time        `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    *time.Time       `json:"updated_at" gorm:"autoUpdateTime:false"`
+	DeletedAt    gorm.DeletedAt   `gorm:"index" json:"deleted_at"`
+	Hidden       bool             `json:"hidden"`
+	Version      uint             `json:"version" gorm:"not null;default:1"` // 동시 편집 충돌 감지용
+	Protected    bool             `json:"protected"`
+	Password     []byte           `json:"-"`                         // 보호된 포스트의 bcrypt 해시
+	Locked       bool             `json:"locked,omitempty" gorm:"-"` // 비밀번호를 입력하지 않아 내용을 숨김
+	UserID       uint             `json:"user_id"`
+	User         User             `json:"user" gorm:"foreignKey:UserID"`
+	CommentCount int              `json:"comment_count" gorm:"-"`
+	Authors      []User           `json:"authors" gorm:"-"` // 작성자와 초대를 수락한 공동 작성자
+	Languages    []PostLanguage   `json:"languages,omitempty" gorm:"-"`
+	Attachments  []PostAttachment `json:"attachments,omitempty" gorm:"foreignKey:PostID"`
+}
+
+// SetPassword protects the post with password, or removes the protection
+// when password is empty
+func (p *Post) SetPassword(password string) {
+	if password == "" {
+		p.Password = nil
+		p.Protected = false
+		return
+	}
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), 14)
+	p.Password = hashedPassword
+	p.Protected = true
+}
+
+func (p *Post) ComparePassword(password string) error {
+	return bcrypt.CompareHashAndPassword(p.Password, []byte(password))
 }
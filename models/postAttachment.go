@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// PostAttachment is a file attached to a post, stored in uploads/<PostID>/<Filename>
+type PostAttachment struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	PostID        uint      `json:"post_id" gorm:"index"`
+	Filename      string    `json:"filename"`
+	Name          string    `json:"name"` // 화면에 표시할 이름
+	Size          int64     `json:"size"`
+	MimeType      string    `json:"mime_type" gorm:"size:100"`
+	Position      int       `json:"position"`
+	DownloadCount int       `json:"download_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PostCollaborator gives another user access to a post. The invitation is
+// pending until the invited user accepts it.
+type PostCollaborator struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	PostID     uint       `json:"post_id" gorm:"uniqueIndex:idx_post_collaborator"`
+	UserID     uint       `json:"user_id" gorm:"uniqueIndex:idx_post_collaborator"`
+	User       User       `json:"user" gorm:"foreignKey:UserID"`
+	InvitedBy  uint       `json:"invited_by"`
+	CanEdit    bool       `json:"can_edit"`    // update the title, content, tags and file
+	CanPublish bool       `json:"can_publish"` // hide and unhide the post
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Accepted reports whether the invited user accepted the invitation
+func (pc *PostCollaborator) Accepted() bool {
+	return pc != nil && pc.AcceptedAt != nil
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// PostDailyView counts the views of a post on one day
+type PostDailyView struct {
+	PostID uint      `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Day    time.Time `json:"day" gorm:"primaryKey;type:date"`
+	Views  int64     `json:"views" gorm:"not null;default:0"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PostImport links an imported post to the document it came from so that
+// re-importing the same source updates the post instead of duplicating it
+type PostImport struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Source    string    `json:"source" gorm:"size:32;uniqueIndex:idx_post_import_source"`
+	SourceID  string    `json:"source_id" gorm:"size:191;uniqueIndex:idx_post_import_source"`
+	PostID    uint      `json:"post_id" gorm:"index"`
+	Checksum  string    `json:"checksum" gorm:"size:64"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PostShareLink lets anyone with its token read a post, even a hidden one,
+// until it expires or is revoked. Only the SHA-256 hash of the token is stored.
+type PostShareLink struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	PostID        uint       `json:"post_id" gorm:"index"`
+	TokenHash     string     `json:"-" gorm:"size:64;uniqueIndex"`
+	TokenHint     string     `json:"token_hint" gorm:"size:8"` // 링크를 구분하기 위한 토큰 앞부분
+	Note          string     `json:"note"`
+	AllowComments bool       `json:"allow_comments"` // read and write comments through the link
+	CreatedBy     uint       `json:"created_by"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Active reports whether the link can still be used
+func (l *PostShareLink) Active() bool {
+	return l != nil && l.RevokedAt == nil && time.Now().Before(l.ExpiresAt)
+}
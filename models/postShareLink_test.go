@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPostShareLinkActive(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name string
+		link *PostShareLink
+		want bool
+	}{
+		{"nil", nil, false},
+		{"active", &PostShareLink{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", &PostShareLink{ExpiresAt: now.Add(-time.Second)}, false},
+		{"revoked", &PostShareLink{ExpiresAt: now.Add(time.Hour), RevokedAt: &now}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.link.Active(); got != tt.want {
+			t.Errorf("%s: Active = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PostTranslation is a language variant of a post. The post itself holds
+// the original in Post.Lang.
+type PostTranslation struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"uniqueIndex:idx_post_translation"`
+	Lang      string    `json:"lang" gorm:"size:10;uniqueIndex:idx_post_translation"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug" gorm:"size:191;index"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PostLanguage describes one language a post is available in
+type PostLanguage struct {
+	Lang     string `json:"lang"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Original bool   `json:"original"`
+}
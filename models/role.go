@@ -0,0 +1,65 @@
+package models
+
+// Roles a user can have, from most to least privileged
+const (
+	RoleAdmin     = "admin"
+	RoleEditor    = "editor"
+	RoleAuthor    = "author"
+	RoleCommenter = "commenter"
+)
+
+// Permission is an action guarded by middleware.RequirePermission
+type Permission string
+
+const (
+	PermCreatePost       Permission = "post:create"
+	PermEditAnyPost      Permission = "post:edit_any"
+	PermViewHiddenPosts  Permission = "post:view_hidden"
+	PermModerateComments Permission = "comment:moderate"
+	PermManageAbout      Permission = "about:manage"
+	PermUploadFile       Permission = "file:upload"
+	PermImportExport     Permission = "blog:import_export"
+	PermManageUsers      Permission = "user:manage"
+)
+
+var rolePermissions = map[string][]Permission{
+	RoleEditor: {
+		PermCreatePost,
+		PermEditAnyPost,
+		PermViewHiddenPosts,
+		PermModerateComments,
+		PermManageAbout,
+		PermUploadFile,
+	},
+	RoleAuthor: {
+		PermCreatePost,
+		PermUploadFile,
+	},
+	RoleCommenter: {},
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Can reports whether the user's role grants the permission. Blocked users
+// have no permissions.
+func (u *User) Can(p Permission) bool {
+	if u == nil || u.ID == 0 || u.Blocked {
+		return false
+	}
+	if u.Role == RoleAdmin {
+		return true
+	}
+	for _, perm := range rolePermissions[u.Role] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package models
+
+import "testing"
+
+func TestValidRole(t *testing.T) {
+	for role, want := range map[string]bool{
+		RoleAdmin:     true,
+		RoleEditor:    true,
+		RoleAuthor:    true,
+		RoleCommenter: true,
+		"":            false,
+		"Admin":       false,
+		"owner":       false,
+	} {
+		if got := ValidRole(role); got != want {
+			t.Errorf("ValidRole(%q) = %t, want %t", role, got, want)
+		}
+	}
+}
+
+func TestUserCan(t *testing.T) {
+	tests := []struct {
+		name string
+		user *User
+		perm Permission
+		want bool
+	}{
+		{"anonymous", nil, PermCreatePost, false},
+		{"unsaved user", &User{Role: RoleAdmin}, PermCreatePost, false},
+		{"admin can do anything", &User{ID: 1, Role: RoleAdmin}, PermManageUsers, true},
+		{"blocked admin", &User{ID: 1, Role: RoleAdmin, Blocked: true}, PermCreatePost, false},
+		{"editor moderates", &User{ID: 1, Role: RoleEditor}, PermModerateComments, true},
+		{"editor cannot manage users", &User{ID: 1, Role: RoleEditor}, PermManageUsers, false},
+		{"editor cannot export", &User{ID: 1, Role: RoleEditor}, PermImportExport, false},
+		{"author writes", &User{ID: 1, Role: RoleAuthor}, PermCreatePost, true},
+		{"author uploads", &User{ID: 1, Role: RoleAuthor}, PermUploadFile, true},
+		{"author cannot edit others", &User{ID: 1, Role: RoleAuthor}, PermEditAnyPost, false},
+		{"commenter", &User{ID: 1, Role: RoleCommenter}, PermCreatePost, false},
+		{"unknown role", &User{ID: 1, Role: "owner"}, PermCreatePost, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.Can(tt.perm); got != tt.want {
+				t.Errorf("Can(%s) = %t, want %t", tt.perm, got, tt.want)
+			}
+		})
+	}
+}
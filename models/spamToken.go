@@ -0,0 +1,9 @@
+package models
+
+// SpamToken counts the spam and ham comments a word appeared in, for the
+// Bayes classifier of the spam package
+type SpamToken struct {
+	Token string `json:"token" gorm:"primaryKey;size:191"`
+	Spam  int64  `json:"spam" gorm:"not null;default:0"`
+	Ham   int64  `json:"ham" gorm:"not null;default:0"`
+}
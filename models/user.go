@@ -3,15 +3,20 @@ package models
 import "golang.org/x/crypto/bcrypt"
 
 type User struct {
-	ID            uint   `json:"id" gorm:"primaryKey"`
-	FirstName     string `json:"first_name"`
-	LastName      string `json:"last_name"`
-	Email         string `json:"email"`
-	GithubID      int64  `json:"github_id"`
-	PublicAddress string `json:"public_address"`
-	Password      []byte `json:"-"` // - means that this field will not be returned in the response
-	Phone         string `json:"phone"`
-	Picture       string `json:"picture"`
+	ID            uint    `json:"id" gorm:"primaryKey"`
+	Username      *string `json:"username" gorm:"size:32;uniqueIndex"` // @멘션에 쓰는 이름, 없을 수 있음
+	FirstName     string  `json:"first_name"`
+	LastName      string  `json:"last_name"`
+	Email         string  `json:"email"`
+	GithubID      int64   `json:"github_id"`
+	PublicAddress string  `json:"public_address"`
+	Password      []byte  `json:"-"` // - means that this field will not be returned in the response
+	Phone         string  `json:"phone"`
+	Picture       string  `json:"picture"`
+	Role          string  `json:"role" gorm:"size:20;default:author"`
+	Blocked       bool    `json:"blocked"`
+	EmailOptOut   bool    `json:"email_opt_out"`          // 댓글·답글 알림 메일 수신 거부
+	Language      string  `json:"language" gorm:"size:5"` // 알림 메일 언어 (ko, en)
 }
 
 func (u *User) SetPassword(password string) {
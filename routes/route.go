@@ -5,11 +5,18 @@ import (
 
 	"github.com/bloomingFlower/blog-backend/controller"
 	"github.com/bloomingFlower/blog-backend/middleware"
+	"github.com/bloomingFlower/blog-backend/models"
+	"github.com/bloomingFlower/blog-backend/util"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/limiter"
 )
 
 func Setup(app *fiber.App) {
+	// 요청 본문 크기 제한, 가져오기 파일만 크게 허용
+	app.Use(middleware.LimitBody(fiber.DefaultBodyLimit, map[string]int{
+		"/api/v1/import": controller.MaxImportSize,
+	}))
+
 	// API v1 그룹 생성 및 속도 제한 설정
 	v1 := app.Group("/api/v1", limiter.New(limiter.Config{
 		Max:        100,
@@ -35,46 +42,112 @@ func Setup(app *fiber.App) {
 	user.Delete("", controller.DeleteUser)
 	user.Put("", controller.UpdateUser)
 
-	// 정적 파일 서빙
-	v1.Static("/download", "./uploads")
+	// 사용자 권한 관리 라우트
+	v1.Put("/users/:id/role", middleware.IsAuthenticate, middleware.RequirePermission(models.PermManageUsers), controller.UpdateUserRole)
+
+	// 업로드된 파일 서빙, 포스트를 읽을 수 있을 때만 제공
+	v1.Get("/download/:id/:filename", middleware.OptionalAuthenticate, controller.ServeUpload)
+	v1.Get("/uploads/:id/:filename", middleware.OptionalAuthenticate, controller.ServeUpload)
 
 	// 포스트 관련 라우트
 	posts := v1.Group("/posts")
-	posts.Get("", controller.AllPost)
-	posts.Get("/search", controller.SearchPost)
-	posts.Post("", middleware.IsAuthenticate, controller.CreatePost)
+	posts.Get("", middleware.OptionalAuthenticate, controller.AllPost)
+	posts.Get("/search", middleware.OptionalAuthenticate, controller.SearchPost)
+	posts.Post("", middleware.IsAuthenticate, middleware.RequirePermission(models.PermCreatePost), controller.CreatePost)
+	posts.Get("/trash", middleware.IsAuthenticate, controller.TrashPosts)
+	posts.Post("/bulk", middleware.IsAuthenticate, controller.BulkPosts)
+
+	// 비밀번호 추측을 막기 위해 포스트와 IP별로 실패한 시도 횟수 제한
+	unlockLimiter := limiter.New(limiter.Config{
+		Max:                    5,
+		Expiration:             15 * time.Minute,
+		SkipSuccessfulRequests: true,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.Params("id") + ":" + util.HashIP(c.IP())
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"message": "Too many password attempts. Please try again later.",
+			})
+		},
+	})
 
 	post := v1.Group("/post")
-	post.Get("/:id", controller.DetailPost)
+	post.Get("/:id", middleware.OptionalAuthenticate, controller.DetailPost)
+	post.Post("/:id/unlock", unlockLimiter, middleware.OptionalAuthenticate, controller.UnlockPost)
 	post.Put("/:id", middleware.IsAuthenticate, controller.UpdatePost)
 	post.Put("/:id/hide", middleware.IsAuthenticate, controller.HidePost)
 	post.Delete("/:id", middleware.IsAuthenticate, controller.DeletePost)
-
-	v1.Get("/unique-post", controller.UniquePost)
+	post.Put("/:id/restore", middleware.IsAuthenticate, controller.RestorePost)
+	post.Delete("/:id/purge", middleware.IsAuthenticate, controller.PurgePost)
+
+	// 공동 작성자 관련 라우트
+	post.Get("/:id/collaborators", middleware.IsAuthenticate, controller.GetCollaborators)
+	post.Post("/:id/collaborators", middleware.IsAuthenticate, controller.InviteCollaborator)
+	post.Put("/:id/collaborators/:userId", middleware.IsAuthenticate, controller.UpdateCollaborator)
+	post.Delete("/:id/collaborators/:userId", middleware.IsAuthenticate, controller.RemoveCollaborator)
+	v1.Get("/invitations", middleware.IsAuthenticate, controller.GetInvitations)
+	v1.Post("/invitations/:id/accept", middleware.IsAuthenticate, controller.AcceptInvitation)
+	v1.Delete("/invitations/:id", middleware.IsAuthenticate, controller.DeclineInvitation)
+
+	// 번역 관련 라우트
+	post.Get("/:id/translations", middleware.OptionalAuthenticate, controller.GetTranslations)
+	post.Put("/:id/translations/:lang", middleware.IsAuthenticate, controller.SaveTranslation)
+	post.Delete("/:id/translations/:lang", middleware.IsAuthenticate, controller.DeleteTranslation)
+
+	// 첨부파일 관련 라우트
+	post.Get("/:id/attachments", middleware.OptionalAuthenticate, controller.GetAttachments)
+	post.Post("/:id/attachments", middleware.IsAuthenticate, middleware.RequirePermission(models.PermUploadFile), controller.AddAttachments)
+	post.Put("/:id/attachments/order", middleware.IsAuthenticate, controller.ReorderAttachments)
+	post.Put("/:id/attachments/:attachmentId", middleware.IsAuthenticate, controller.UpdateAttachment)
+	post.Delete("/:id/attachments/:attachmentId", middleware.IsAuthenticate, controller.DeleteAttachment)
+	post.Get("/:id/attachments/:attachmentId/download", middleware.OptionalAuthenticate, controller.DownloadAttachment)
+
+	// 공유 링크 관련 라우트
+	post.Get("/:id/share-links", middleware.IsAuthenticate, controller.GetShareLinks)
+	post.Post("/:id/share-links", middleware.IsAuthenticate, controller.CreateShareLink)
+	post.Delete("/:id/share-links/:linkId", middleware.IsAuthenticate, controller.RevokeShareLink)
+
+	v1.Get("/unique-post", middleware.IsAuthenticate, controller.UniquePost)
+	v1.Get("/dashboard", middleware.IsAuthenticate, controller.AuthorDashboard)
 	v1.Get("/rss", controller.RSSFeed)
 
+	// 크롤러용 메타 페이지
+	v1.Get("/meta/post/:key", controller.PostMeta)
+	v1.Get("/oembed", controller.OEmbed)
+
 	// 나는
+	manageAbout := []fiber.Handler{middleware.IsAuthenticate, middleware.RequirePermission(models.PermManageAbout)}
 	v1.Get("/about-me/:id", controller.GetAboutInfo)
-	v1.Post("/about-me", controller.CreateAboutInfo)
-	v1.Put("/about-me/:id", controller.UpdateAboutInfo)
-	v1.Delete("/about-me/:id", controller.DeleteAboutInfo)
+	v1.Post("/about-me", append(manageAbout, controller.CreateAboutInfo)...)
+	v1.Put("/about-me/:id", append(manageAbout, controller.UpdateAboutInfo)...)
+	v1.Delete("/about-me/:id", append(manageAbout, controller.DeleteAboutInfo)...)
 
 	// Section routes
-	v1.Post("/about-me/:aboutInfoID/sections", controller.CreateSection)
-	v1.Put("/about-me/:aboutInfoID/sections/:sectionID", controller.UpdateSection)
-	v1.Delete("/about-me/:aboutInfoID/sections/:sectionID", controller.DeleteSection)
+	v1.Post("/about-me/:aboutInfoID/sections", append(manageAbout, controller.CreateSection)...)
+	v1.Put("/about-me/:aboutInfoID/sections/:sectionID", append(manageAbout, controller.UpdateSection)...)
+	v1.Delete("/about-me/:aboutInfoID/sections/:sectionID", append(manageAbout, controller.DeleteSection)...)
 
 	// SectionItem routes
-	v1.Post("/about-me/:aboutInfoID/sections/:sectionID/items", controller.CreateSectionItem)
-	v1.Put("/about-me/:aboutInfoID/sections/:sectionID/items/:itemID", controller.UpdateSectionItem)
-	v1.Delete("/about-me/:aboutInfoID/sections/:sectionID/items/:itemID", controller.DeleteSectionItem)
+	v1.Post("/about-me/:aboutInfoID/sections/:sectionID/items", append(manageAbout, controller.CreateSectionItem)...)
+	v1.Put("/about-me/:aboutInfoID/sections/:sectionID/items/:itemID", append(manageAbout, controller.UpdateSectionItem)...)
+	v1.Delete("/about-me/:aboutInfoID/sections/:sectionID/items/:itemID", append(manageAbout, controller.DeleteSectionItem)...)
 
 	// 파일 업로드 관련 라우트
-	v1.Post("/upload-file", middleware.IsAuthenticate, controller.UploadFile)
-	v1.Post("/upload-img", middleware.IsAuthenticate, controller.UploadImage)
+	v1.Post("/upload-file", middleware.IsAuthenticate, middleware.RequirePermission(models.PermUploadFile), controller.UploadFile)
+	v1.Post("/upload-img", middleware.IsAuthenticate, middleware.RequirePermission(models.PermUploadFile), controller.UploadImage)
 	v1.Get("/files/:id/:filename", middleware.IsAuthenticate, controller.ServeFile)
 
-	app.Get("/api/v1/post/:id/og-image", controller.GenerateOGImage)
+	// OG 이미지 관련 라우트
+	v1.Get("/post/:id/og-image", controller.GenerateOGImage)
+	v1.Get("/category/:name/og-image", controller.GenerateCategoryOGImage)
+	v1.Get("/tag/:name/og-image", controller.GenerateTagOGImage)
+	v1.Get("/author/:id/og-image", controller.GenerateAuthorOGImage)
+	v1.Get("/about-me/:id/og-image", controller.GenerateAboutOGImage)
+
+	// 가져오기/내보내기 관련 라우트
+	v1.Post("/import", middleware.IsAuthenticate, middleware.RequirePermission(models.PermImportExport), controller.ImportPosts)
+	v1.Get("/export", middleware.IsAuthenticate, middleware.RequirePermission(models.PermImportExport), controller.ExportBlog)
 
 	// 소셜 로그인 관련 라우트
 	auth := v1.Group("/auth")
@@ -86,9 +159,36 @@ func Setup(app *fiber.App) {
 
 	// 댓글 관련 라우트
 	comments := v1.Group("/comments")
-	comments.Get("/:postId", controller.GetComments)
-	comments.Post("/:postId", controller.CreateComment)
-	comments.Post("/:postId/:commentId/replies", controller.CreateReply)
-	comments.Post("/:postId/:commentId/vote", controller.VoteComment)
-	comments.Delete("/:postId/:commentId", controller.DeleteComment)
+	comments.Get("/:postId", middleware.OptionalAuthenticate, controller.GetComments)
+	comments.Post("/:postId", middleware.OptionalAuthenticate, controller.CreateComment)
+	comments.Post("/:postId/:commentId/replies", middleware.OptionalAuthenticate, controller.CreateReply)
+	comments.Post("/:postId/:commentId/vote", middleware.OptionalAuthenticate, controller.VoteComment)
+	comments.Delete("/:postId/:commentId/vote", middleware.OptionalAuthenticate, controller.RemoveCommentReaction)
+	comments.Put("/:postId/:commentId", middleware.OptionalAuthenticate, controller.EditComment)
+	comments.Delete("/:postId/:commentId", middleware.OptionalAuthenticate, controller.DeleteComment)
+	comments.Get("/:postId/:commentId/thread", middleware.OptionalAuthenticate, controller.GetCommentThread)
+	comments.Get("/:postId/:commentId/replies", middleware.OptionalAuthenticate, controller.GetCommentReplies)
+	comments.Get("/:postId/:commentId/history", middleware.IsAuthenticate, middleware.RequirePermission(models.PermModerateComments), controller.GetCommentHistory)
+
+	// 댓글 검토 관련 라우트
+	moderateComments := []fiber.Handler{middleware.IsAuthenticate, middleware.RequirePermission(models.PermModerateComments)}
+	moderation := v1.Group("/moderation")
+	moderation.Get("/comments", append(moderateComments, controller.GetModerationQueue)...)
+	moderation.Post("/comments", append(moderateComments, controller.ModerateComments)...)
+	moderation.Get("/policy", append(moderateComments, controller.GetCommentPolicy)...)
+	moderation.Put("/policy", append(moderateComments, controller.UpdateCommentPolicy)...)
+	moderation.Get("/audit", append(moderateComments, controller.GetCommentAudit)...)
+	post.Get("/:id/comment-policy", middleware.IsAuthenticate, controller.GetPostCommentPolicy)
+	post.Put("/:id/comment-policy", middleware.IsAuthenticate, controller.UpdatePostCommentPolicy)
+	post.Delete("/:id/comment-policy", middleware.IsAuthenticate, controller.DeletePostCommentPolicy)
+
+	// 알림 관련 라우트
+	notifications := v1.Group("/notifications")
+	notifications.Use(middleware.IsAuthenticate)
+	notifications.Get("", controller.GetNotifications)
+	notifications.Post("/read", controller.ReadNotifications)
+	notifications.Get("/settings", controller.GetNotificationSettings)
+	notifications.Put("/settings", controller.UpdateNotificationSettings)
+	v1.Get("/unsubscribe", controller.UnsubscribePage)
+	v1.Post("/unsubscribe", controller.Unsubscribe)
 }
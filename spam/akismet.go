@@ -0,0 +1,113 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultAkismetEndpoint is the Akismet REST API
+const DefaultAkismetEndpoint = "https://rest.akismet.com"
+
+// Akismet checks comments with an Akismet-compatible HTTP API and reports
+// moderator decisions back to it. Endpoint can point at a self-hosted
+// service or a local stub.
+type Akismet struct {
+	Endpoint string
+	Key      string
+	Blog     string // URL of the blog
+	Score    float64
+	Client   *http.Client
+}
+
+func (a *Akismet) client() *http.Client {
+	if a.Client != nil {
+		return a.Client
+	}
+	return &http.Client{Timeout: 5 * time.Second}
+}
+
+func (a *Akismet) form(c *Comment) url.Values {
+	commentType := "comment"
+	if c.Reply {
+		commentType = "reply"
+	}
+	return url.Values{
+		"api_key":              {a.Key},
+		"blog":                 {a.Blog},
+		"user_ip":              {c.IP},
+		"user_agent":           {c.UserAgent},
+		"referrer":             {c.Referrer},
+		"permalink":            {c.Permalink},
+		"comment_type":         {commentType},
+		"comment_author":       {c.Author},
+		"comment_author_email": {c.AuthorEmail},
+		"comment_content":      {c.Content},
+	}
+}
+
+// call posts the comment to an API method and returns the response body
+func (a *Akismet) call(ctx context.Context, method string, c *Comment) (string, http.Header, error) {
+	endpoint := strings.TrimSuffix(a.Endpoint, "/") + "/1.1/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(a.form(c).Encode()))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "blog-backend/1.0")
+
+	resp, err := a.client().Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("akismet %s: %s", method, resp.Status)
+	}
+	return strings.TrimSpace(string(body)), resp.Header, nil
+}
+
+func (a *Akismet) Check(ctx context.Context, c *Comment) (Verdict, error) {
+	body, header, err := a.call(ctx, "comment-check", c)
+	if err != nil {
+		return Verdict{}, err
+	}
+	switch body {
+	case "true":
+		score := a.Score
+		// 확실한 스팸은 검토 없이 거부
+		if header.Get("X-akismet-pro-tip") == "discard" {
+			score *= 2
+		}
+		return Verdict{Score: score, Reason: "akismet"}, nil
+	case "false":
+		return Verdict{}, nil
+	}
+	if help := header.Get("X-akismet-debug-help"); help != "" {
+		return Verdict{}, fmt.Errorf("akismet comment-check: %s", help)
+	}
+	return Verdict{}, fmt.Errorf("akismet comment-check: unexpected response %q", body)
+}
+
+// Learn submits a moderator decision
+func (a *Akismet) Learn(ctx context.Context, c *Comment, spam bool) error {
+	method := "submit-ham"
+	if spam {
+		method = "submit-spam"
+	}
+	_, _, err := a.call(ctx, method, c)
+	return err
+}
+
+// Forget does nothing; Akismet learns corrections from the next Learn
+func (a *Akismet) Forget(ctx context.Context, c *Comment, spam bool) error {
+	return nil
+}
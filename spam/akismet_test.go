@@ -0,0 +1,73 @@
+package spam
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAkismetCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		header  map[string]string
+		status  int
+		want    Verdict
+		wantErr bool
+	}{
+		{"ham", "false", nil, http.StatusOK, Verdict{}, false},
+		{"spam", "true", nil, http.StatusOK, Verdict{Score: 0.75, Reason: "akismet"}, false},
+		{"certain spam", "true", map[string]string{"X-akismet-pro-tip": "discard"}, http.StatusOK, Verdict{Score: 1.5, Reason: "akismet"}, false},
+		{"invalid key", "invalid", map[string]string{"X-akismet-debug-help": "bad key"}, http.StatusOK, Verdict{}, true},
+		{"server error", "", nil, http.StatusInternalServerError, Verdict{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form map[string][]string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/1.1/comment-check" {
+					t.Errorf("path = %s", r.URL.Path)
+				}
+				r.ParseForm()
+				form = r.PostForm
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			a := &Akismet{Endpoint: server.URL + "/", Key: "key", Blog: "https://blog.test", Score: 0.75}
+			got, err := a.Check(context.Background(), &Comment{Content: "hello", IP: "10.0.0.1", Reply: true})
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("Check = %+v, %v, want %+v, error %t", got, err, tt.want, tt.wantErr)
+			}
+			if form["comment_type"][0] != "reply" || form["user_ip"][0] != "10.0.0.1" || form["api_key"][0] != "key" {
+				t.Errorf("form = %v", form)
+			}
+		})
+	}
+}
+
+func TestAkismetLearn(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte("Thanks for making the web a better place."))
+	}))
+	defer server.Close()
+
+	a := &Akismet{Endpoint: server.URL, Key: "key"}
+	ctx := context.Background()
+	if err := a.Learn(ctx, &Comment{}, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Learn(ctx, &Comment{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || paths[0] != "/1.1/submit-spam" || paths[1] != "/1.1/submit-ham" {
+		t.Errorf("paths = %q", paths)
+	}
+}
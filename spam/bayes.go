@@ -0,0 +1,131 @@
+package spam
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// documentsToken counts the trained comments; the tokenizer never
+	// produces it because it only keeps letters and digits
+	documentsToken = "*documents*"
+	// interestingTokens is how many tokens, furthest from neutral, are combined
+	interestingTokens = 15
+)
+
+// TokenCount is how many spam and ham comments contained a token
+type TokenCount struct {
+	Spam int64
+	Ham  int64
+}
+
+// TokenStore keeps the counts of a Bayes classifier
+type TokenStore interface {
+	Counts(ctx context.Context, tokens []string) (map[string]TokenCount, error)
+	// Add adds delta to the spam or ham count of every token
+	Add(ctx context.Context, tokens []string, spam bool, delta int64) error
+}
+
+// Bayes is a naive Bayes classifier trained from moderator decisions. It
+// stays silent until it has seen MinDocuments spam and ham comments.
+type Bayes struct {
+	Store        TokenStore
+	MinDocuments int64
+	// Weight is the score of a comment that is certainly spam. Comments
+	// with a spam probability of 0.5 or less score 0.
+	Weight float64
+}
+
+// tokenize returns the distinct lowercase words of the content and the hosts
+// it links to
+func tokenize(content string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+	for _, link := range links(content) {
+		if host := linkHost(link); host != "" {
+			add("host:" + host)
+		}
+	}
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if n := len([]rune(word)); n >= 2 && n <= 40 {
+			add(word)
+		}
+	}
+	return tokens
+}
+
+// SpamProbability returns how likely the content is spam, between 0 and 1,
+// and false when the classifier is not trained enough
+func (b *Bayes) SpamProbability(ctx context.Context, content string) (float64, bool, error) {
+	tokens := tokenize(content)
+	counts, err := b.Store.Counts(ctx, append(tokens, documentsToken))
+	if err != nil {
+		return 0, false, err
+	}
+	docs := counts[documentsToken]
+	if docs.Spam < b.MinDocuments || docs.Ham < b.MinDocuments || docs.Spam == 0 || docs.Ham == 0 {
+		return 0, false, nil
+	}
+
+	// Robinson's smoothed probability of each token, starting from 0.5 for
+	// tokens that were rarely seen
+	var probabilities []float64
+	for _, token := range tokens {
+		count := counts[token]
+		n := float64(count.Spam + count.Ham)
+		if n == 0 {
+			continue
+		}
+		spamRate := float64(count.Spam) / float64(docs.Spam)
+		hamRate := float64(count.Ham) / float64(docs.Ham)
+		p := spamRate / (spamRate + hamRate)
+		p = (0.5 + n*p) / (1 + n)
+		probabilities = append(probabilities, math.Min(math.Max(p, 0.01), 0.99))
+	}
+	if len(probabilities) == 0 {
+		return 0.5, true, nil
+	}
+	sort.Slice(probabilities, func(i, j int) bool {
+		return math.Abs(probabilities[i]-0.5) > math.Abs(probabilities[j]-0.5)
+	})
+	if len(probabilities) > interestingTokens {
+		probabilities = probabilities[:interestingTokens]
+	}
+
+	var logOdds float64
+	for _, p := range probabilities {
+		logOdds += math.Log(p / (1 - p))
+	}
+	return 1 / (1 + math.Exp(-logOdds)), true, nil
+}
+
+func (b *Bayes) Check(ctx context.Context, c *Comment) (Verdict, error) {
+	p, trained, err := b.SpamProbability(ctx, c.Content)
+	if err != nil || !trained || p <= 0.5 {
+		return Verdict{}, err
+	}
+	return Verdict{
+		Score:  (p - 0.5) * 2 * b.Weight,
+		Reason: "classified as spam",
+	}, nil
+}
+
+func (b *Bayes) Learn(ctx context.Context, c *Comment, spam bool) error {
+	return b.Store.Add(ctx, append(tokenize(c.Content), documentsToken), spam, 1)
+}
+
+func (b *Bayes) Forget(ctx context.Context, c *Comment, spam bool) error {
+	return b.Store.Add(ctx, append(tokenize(c.Content), documentsToken), spam, -1)
+}
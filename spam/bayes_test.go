@@ -0,0 +1,133 @@
+package spam
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// memStore is a TokenStore in memory
+type memStore map[string]TokenCount
+
+func (m memStore) Counts(ctx context.Context, tokens []string) (map[string]TokenCount, error) {
+	counts := make(map[string]TokenCount)
+	for _, token := range tokens {
+		if count, ok := m[token]; ok {
+			counts[token] = count
+		}
+	}
+	return counts, nil
+}
+
+func (m memStore) Add(ctx context.Context, tokens []string, spam bool, delta int64) error {
+	for _, token := range tokens {
+		count := m[token]
+		if spam {
+			count.Spam = max(count.Spam+delta, 0)
+		} else {
+			count.Ham = max(count.Ham+delta, 0)
+		}
+		m[token] = count
+	}
+	return nil
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"empty", "", nil},
+		{"lowercase and distinct", "Hello hello WORLD", []string{"hello", "world"}},
+		{"punctuation splits words", "buy-now!!! cheap,pills", []string{"buy", "now", "cheap", "pills"}},
+		{"single letters are dropped", "a b cd", []string{"cd"}},
+		{"too long words are dropped", "abcdefghijklmnopqrstuvwxyzabcdefghijklmno ok", []string{"ok"}},
+		{"hosts of links come first", "see https://Spam.example.com/a and www.shop.test",
+			[]string{"host:spam.example.com", "host:www.shop.test", "see", "https", "spam", "example", "com", "and", "www", "shop", "test"}},
+		{"korean words", "안녕하세요 반가워요 안녕하세요", []string{"안녕하세요", "반가워요"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tokenize(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenize(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+// trainedStore has seen 10 spam and 10 ham comments
+func trainedStore() memStore {
+	return memStore{
+		documentsToken: {Spam: 10, Ham: 10},
+		"casino":       {Spam: 9, Ham: 0},
+		"bonus":        {Spam: 6, Ham: 1},
+		"thanks":       {Spam: 0, Ham: 8},
+		"article":      {Spam: 1, Ham: 7},
+		"post":         {Spam: 5, Ham: 5},
+	}
+}
+
+func TestBayesSpamProbability(t *testing.T) {
+	tests := []struct {
+		name        string
+		store       memStore
+		min         int64
+		content     string
+		wantTrained bool
+		check       func(p float64) bool
+		want        string
+	}{
+		{"untrained", memStore{}, 0, "casino bonus", false, func(p float64) bool { return p == 0 }, "0"},
+		{"below the minimum", trainedStore(), 20, "casino bonus", false, func(p float64) bool { return p == 0 }, "0"},
+		{"no ham seen", memStore{documentsToken: {Spam: 10}}, 0, "casino", false, func(p float64) bool { return p == 0 }, "0"},
+		{"unknown words are neutral", trainedStore(), 10, "completely unseen words", true, func(p float64) bool { return p == 0.5 }, "0.5"},
+		{"spam words", trainedStore(), 10, "casino bonus", true, func(p float64) bool { return p > 0.95 }, "> 0.95"},
+		{"ham words", trainedStore(), 10, "thanks for the article", true, func(p float64) bool { return p < 0.05 }, "< 0.05"},
+		{"evenly seen words are neutral", trainedStore(), 10, "post", true, func(p float64) bool { return math.Abs(p-0.5) < 1e-9 }, "0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Bayes{Store: tt.store, MinDocuments: tt.min, Weight: 1}
+			p, trained, err := b.SpamProbability(context.Background(), tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if trained != tt.wantTrained || !tt.check(p) {
+				t.Errorf("SpamProbability(%q) = %v, %t, want %s, %t", tt.content, p, trained, tt.want, tt.wantTrained)
+			}
+		})
+	}
+}
+
+func TestBayesLearnAndForget(t *testing.T) {
+	store := memStore{}
+	b := &Bayes{Store: store, MinDocuments: 1, Weight: 2}
+	ctx := context.Background()
+
+	b.Learn(ctx, &Comment{Content: "cheap casino bonus"}, true)
+	b.Learn(ctx, &Comment{Content: "thanks for the post"}, false)
+	if got := store[documentsToken]; got != (TokenCount{Spam: 1, Ham: 1}) {
+		t.Fatalf("documents = %+v, want 1 spam and 1 ham", got)
+	}
+
+	verdict, err := b.Check(ctx, &Comment{Content: "casino bonus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Score <= 0 || verdict.Score > 2 || verdict.Reason != "classified as spam" {
+		t.Errorf("Check = %+v, want a score between 0 and the weight", verdict)
+	}
+	if verdict, _ := b.Check(ctx, &Comment{Content: "thanks"}); verdict.Score != 0 {
+		t.Errorf("Check of ham = %+v, want no score", verdict)
+	}
+
+	b.Forget(ctx, &Comment{Content: "cheap casino bonus"}, true)
+	if got := store["casino"]; got != (TokenCount{}) {
+		t.Errorf("casino after Forget = %+v, want no counts", got)
+	}
+	if _, trained, _ := b.SpamProbability(ctx, "casino"); trained {
+		t.Error("SpamProbability is trained after forgetting the only spam comment")
+	}
+}
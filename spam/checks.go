@@ -0,0 +1,106 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s"'<>]+`)
+
+// links returns the URLs in the content
+func links(content string) []string {
+	return linkPattern.FindAllString(content, -1)
+}
+
+// linkHost returns the lowercase host of a URL found by links
+func linkHost(link string) string {
+	if strings.HasPrefix(strings.ToLower(link), "www.") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// LinkChecker scores comments with more than Max links. Every extra link
+// adds Weight.
+type LinkChecker struct {
+	Max    int
+	Weight float64
+}
+
+func (l LinkChecker) Check(ctx context.Context, c *Comment) (Verdict, error) {
+	n := len(links(c.Content))
+	if n <= l.Max {
+		return Verdict{}, nil
+	}
+	return Verdict{
+		Score:  float64(n-l.Max) * l.Weight,
+		Reason: fmt.Sprintf("%d links", n),
+	}, nil
+}
+
+// BlocklistChecker rejects comments containing a blocked word or linking to
+// a blocked domain or one of its subdomains
+type BlocklistChecker struct {
+	Words   []string
+	Domains []string
+	Score   float64
+}
+
+func (b BlocklistChecker) Check(ctx context.Context, c *Comment) (Verdict, error) {
+	content := strings.ToLower(c.Content + " " + c.Author)
+	for _, word := range b.Words {
+		if word != "" && strings.Contains(content, strings.ToLower(word)) {
+			return Verdict{Score: b.Score, Reason: "blocked word"}, nil
+		}
+	}
+	for _, link := range links(c.Content) {
+		host := linkHost(link)
+		for _, domain := range b.Domains {
+			domain = strings.ToLower(domain)
+			if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+				return Verdict{Score: b.Score, Reason: "blocked domain " + domain}, nil
+			}
+		}
+	}
+	return Verdict{}, nil
+}
+
+// DuplicateChecker scores comments whose content was already posted. Count
+// returns how many recent comments have the same content.
+type DuplicateChecker struct {
+	Count func(ctx context.Context, c *Comment) (int64, error)
+	Score float64
+}
+
+func (d DuplicateChecker) Check(ctx context.Context, c *Comment) (Verdict, error) {
+	if strings.TrimSpace(c.Content) == "" {
+		return Verdict{}, nil
+	}
+	n, err := d.Count(ctx, c)
+	if err != nil || n == 0 {
+		return Verdict{}, err
+	}
+	return Verdict{
+		Score:  d.Score * float64(n),
+		Reason: "duplicate content",
+	}, nil
+}
+
+// HoneypotChecker rejects comments that filled in the hidden form field
+type HoneypotChecker struct {
+	Score float64
+}
+
+func (h HoneypotChecker) Check(ctx context.Context, c *Comment) (Verdict, error) {
+	if c.Honeypot == "" {
+		return Verdict{}, nil
+	}
+	return Verdict{Score: h.Score, Reason: "honeypot"}, nil
+}
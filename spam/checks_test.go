@@ -0,0 +1,120 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		content   string
+		wantLinks []string
+		wantHosts []string
+	}{
+		{"no links here", nil, nil},
+		{`<a href="https://Example.com/path?q=1">x</a>`, []string{"https://Example.com/path?q=1"}, []string{"example.com"}},
+		{"visit www.shop.test/deals now", []string{"www.shop.test/deals"}, []string{"www.shop.test"}},
+		{"http://a.test:8080 and HTTPS://b.test", []string{"http://a.test:8080", "HTTPS://b.test"}, []string{"a.test", "b.test"}},
+	}
+	for _, tt := range tests {
+		got := links(tt.content)
+		if !reflect.DeepEqual(got, tt.wantLinks) {
+			t.Errorf("links(%q) = %q, want %q", tt.content, got, tt.wantLinks)
+			continue
+		}
+		for i, link := range got {
+			if host := linkHost(link); host != tt.wantHosts[i] {
+				t.Errorf("linkHost(%q) = %q, want %q", link, host, tt.wantHosts[i])
+			}
+		}
+	}
+}
+
+func TestLinkChecker(t *testing.T) {
+	checker := LinkChecker{Max: 2, Weight: 0.25}
+	tests := []struct {
+		content string
+		want    Verdict
+	}{
+		{"no links", Verdict{}},
+		{"https://a.test https://b.test", Verdict{}},
+		{"https://a.test https://b.test https://c.test", Verdict{Score: 0.25, Reason: "3 links"}},
+		{"https://a.test www.b.test https://c.test http://d.test", Verdict{Score: 0.5, Reason: "4 links"}},
+	}
+	for _, tt := range tests {
+		got, err := checker.Check(context.Background(), &Comment{Content: tt.content})
+		if err != nil || got != tt.want {
+			t.Errorf("Check(%q) = %+v, %v, want %+v", tt.content, got, err, tt.want)
+		}
+	}
+}
+
+func TestBlocklistChecker(t *testing.T) {
+	checker := BlocklistChecker{
+		Words:   []string{"Viagra", ""},
+		Domains: []string{"Spam.test", ""},
+		Score:   1,
+	}
+	tests := []struct {
+		name    string
+		comment Comment
+		want    Verdict
+	}{
+		{"clean", Comment{Content: "nice post, see https://good.test"}, Verdict{}},
+		{"word in content", Comment{Content: "cheap VIAGRA here"}, Verdict{Score: 1, Reason: "blocked word"}},
+		{"word in author", Comment{Content: "hello", Author: "viagra shop"}, Verdict{Score: 1, Reason: "blocked word"}},
+		{"domain", Comment{Content: "https://spam.test/x"}, Verdict{Score: 1, Reason: "blocked domain spam.test"}},
+		{"subdomain", Comment{Content: "www.shop.spam.test"}, Verdict{Score: 1, Reason: "blocked domain spam.test"}},
+		{"similar domain", Comment{Content: "https://notspam.test"}, Verdict{}},
+		{"domain only in text", Comment{Content: "spam.test is not a link"}, Verdict{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := checker.Check(context.Background(), &tt.comment)
+			if err != nil || got != tt.want {
+				t.Errorf("Check = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDuplicateChecker(t *testing.T) {
+	countErr := errors.New("count failed")
+	tests := []struct {
+		name    string
+		content string
+		count   int64
+		err     error
+		want    Verdict
+		wantErr error
+	}{
+		{"blank content is not counted", "  ", 3, nil, Verdict{}, nil},
+		{"new content", "hello", 0, nil, Verdict{}, nil},
+		{"posted twice before", "hello", 2, nil, Verdict{Score: 1, Reason: "duplicate content"}, nil},
+		{"count fails", "hello", 0, countErr, Verdict{}, countErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := DuplicateChecker{
+				Count: func(ctx context.Context, c *Comment) (int64, error) { return tt.count, tt.err },
+				Score: 0.5,
+			}
+			got, err := checker.Check(context.Background(), &Comment{Content: tt.content})
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("Check = %+v, %v, want %+v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHoneypotChecker(t *testing.T) {
+	checker := HoneypotChecker{Score: 1}
+	if got, _ := checker.Check(context.Background(), &Comment{}); got != (Verdict{}) {
+		t.Errorf("Check without honeypot = %+v, want no score", got)
+	}
+	if got, _ := checker.Check(context.Background(), &Comment{Honeypot: "bot"}); got != (Verdict{Score: 1, Reason: "honeypot"}) {
+		t.Errorf("Check with honeypot = %+v", got)
+	}
+}
@@ -0,0 +1,90 @@
+package spam
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"gorm.io/gorm"
+)
+
+// duplicateWindow is how far back DuplicateChecker looks for the same content
+const duplicateWindow = 7 * 24 * time.Hour
+
+// FromEnv builds the pipeline used for new comments:
+//
+//	SPAM_HOLD_SCORE       score that holds a comment for moderation (default 0.5)
+//	SPAM_REJECT_SCORE     score that marks a comment as spam (default 1)
+//	SPAM_MAX_LINKS        links allowed before the score goes up (default 2)
+//	SPAM_BLOCKED_WORDS    comma separated words that mark a comment as spam
+//	SPAM_BLOCKED_DOMAINS  comma separated domains that mark a comment as spam
+//	AKISMET_KEY           enables the Akismet check
+//	AKISMET_ENDPOINT      Akismet-compatible API (default https://rest.akismet.com)
+//	AKISMET_BLOG          blog URL sent to Akismet (default BASE_URL)
+func FromEnv(db *gorm.DB) *Pipeline {
+	hold := envFloat("SPAM_HOLD_SCORE", 0.5)
+	reject := envFloat("SPAM_REJECT_SCORE", 1)
+
+	p := &Pipeline{
+		HoldScore:   hold,
+		RejectScore: reject,
+		Checkers: []SpamChecker{
+			HoneypotChecker{Score: reject},
+			BlocklistChecker{
+				Words:   envList("SPAM_BLOCKED_WORDS"),
+				Domains: envList("SPAM_BLOCKED_DOMAINS"),
+				Score:   reject,
+			},
+			LinkChecker{Max: int(envFloat("SPAM_MAX_LINKS", 2)), Weight: hold / 2},
+			DuplicateChecker{
+				Count: func(ctx context.Context, c *Comment) (int64, error) {
+					var count int64
+					err := db.WithContext(ctx).Model(&models.Comment{}).
+						Where("content = ? AND created_at > ?", c.Content, time.Now().Add(-duplicateWindow)).
+						Count(&count).Error
+					return count, err
+				},
+				Score: hold,
+			},
+			&Bayes{Store: GormStore{DB: db}, MinDocuments: 10, Weight: reject},
+		},
+	}
+
+	if key := os.Getenv("AKISMET_KEY"); key != "" {
+		endpoint := os.Getenv("AKISMET_ENDPOINT")
+		if endpoint == "" {
+			endpoint = DefaultAkismetEndpoint
+		}
+		blog := os.Getenv("AKISMET_BLOG")
+		if blog == "" {
+			blog = os.Getenv("BASE_URL")
+		}
+		p.Checkers = append(p.Checkers, &Akismet{
+			Endpoint: endpoint,
+			Key:      key,
+			Blog:     blog,
+			Score:    (hold + reject) / 2,
+		})
+	}
+	return p
+}
+
+func envFloat(name string, def float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(name), 64); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+func envList(name string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,51 @@
+package spam
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("SPAM_HOLD_SCORE", "0.4")
+	t.Setenv("SPAM_REJECT_SCORE", "bad")
+	t.Setenv("SPAM_MAX_LINKS", "3")
+	t.Setenv("SPAM_BLOCKED_WORDS", " casino, ,viagra ")
+	t.Setenv("SPAM_BLOCKED_DOMAINS", "")
+	t.Setenv("AKISMET_KEY", "key")
+	t.Setenv("AKISMET_ENDPOINT", "")
+	t.Setenv("AKISMET_BLOG", "")
+	t.Setenv("BASE_URL", "https://blog.example.com")
+
+	p := FromEnv(nil)
+	if p.HoldScore != 0.4 || p.RejectScore != 1 {
+		t.Errorf("scores = %v, %v, want 0.4, 1", p.HoldScore, p.RejectScore)
+	}
+	if len(p.Checkers) != 6 {
+		t.Fatalf("%d checkers, want 6", len(p.Checkers))
+	}
+	blocklist := p.Checkers[1].(BlocklistChecker)
+	if want := []string{"casino", "viagra"}; !reflect.DeepEqual(blocklist.Words, want) || blocklist.Domains != nil {
+		t.Errorf("blocklist = %+v, want words %q and no domains", blocklist, want)
+	}
+	if links := p.Checkers[2].(LinkChecker); links.Max != 3 || links.Weight != 0.2 {
+		t.Errorf("links = %+v, want Max 3 and Weight 0.2", links)
+	}
+	akismet := p.Checkers[5].(*Akismet)
+	if akismet.Endpoint != DefaultAkismetEndpoint || akismet.Blog != "https://blog.example.com" || akismet.Score != 0.7 {
+		t.Errorf("akismet = %+v", akismet)
+	}
+}
+
+func TestFromEnvWithoutAkismet(t *testing.T) {
+	t.Setenv("AKISMET_KEY", "")
+	t.Setenv("SPAM_HOLD_SCORE", "-1")
+	p := FromEnv(nil)
+	if p.HoldScore != 0.5 {
+		t.Errorf("HoldScore = %v, want the default 0.5", p.HoldScore)
+	}
+	for _, checker := range p.Checkers {
+		if _, ok := checker.(*Akismet); ok {
+			t.Error("Akismet is enabled without AKISMET_KEY")
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package spam scores new comments. A Pipeline runs a list of checkers, adds
+// up their scores and decides whether a comment is published, held for
+// moderation or rejected as spam. Checkers that implement Trainer learn from
+// the decisions of moderators.
+package spam
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// Actions decided by a Pipeline
+const (
+	ActionAllow  = "allow"
+	ActionHold   = "hold"
+	ActionReject = "reject"
+)
+
+// Comment is what the checkers know about a new comment
+type Comment struct {
+	PostID      uint
+	UserID      uint // 0 for anonymous comments
+	Reply       bool
+	Content     string // sanitized HTML
+	Author      string
+	AuthorEmail string
+	IP          string
+	UserAgent   string
+	Referrer    string
+	Permalink   string
+	Honeypot    string // hidden form field that only bots fill in
+}
+
+// Verdict is the score a checker gives a comment. Scores of all checkers are
+// added up; 0 means the checker found nothing.
+type Verdict struct {
+	Score  float64
+	Reason string
+}
+
+// SpamChecker scores a comment
+type SpamChecker interface {
+	Check(ctx context.Context, c *Comment) (Verdict, error)
+}
+
+// Trainer is a SpamChecker that learns from moderator decisions. Forget
+// undoes an earlier Learn with the same label.
+type Trainer interface {
+	Learn(ctx context.Context, c *Comment, spam bool) error
+	Forget(ctx context.Context, c *Comment, spam bool) error
+}
+
+// Result is the outcome of a Pipeline
+type Result struct {
+	Score   float64
+	Reasons []string
+	Action  string
+}
+
+// Reason joins the reasons of every checker that scored the comment
+func (r Result) Reason() string {
+	return strings.Join(r.Reasons, ", ")
+}
+
+// Pipeline runs checkers in order. It stops as soon as the score reaches
+// RejectScore, so cheap local checks should come before remote ones.
+type Pipeline struct {
+	Checkers    []SpamChecker
+	HoldScore   float64
+	RejectScore float64
+}
+
+// Evaluate scores a comment and decides what to do with it. A failing
+// checker is logged and skipped.
+func (p *Pipeline) Evaluate(ctx context.Context, c *Comment) Result {
+	result := Result{Action: ActionAllow}
+	for _, checker := range p.Checkers {
+		verdict, err := checker.Check(ctx, c)
+		if err != nil {
+			log.Printf("spam: %T failed: %v", checker, err)
+			continue
+		}
+		if verdict.Score <= 0 {
+			continue
+		}
+		result.Score += verdict.Score
+		result.Reasons = append(result.Reasons, verdict.Reason)
+		if result.Score >= p.RejectScore {
+			break
+		}
+	}
+
+	switch {
+	case result.Score >= p.RejectScore:
+		result.Action = ActionReject
+	case result.Score >= p.HoldScore:
+		result.Action = ActionHold
+	}
+	return result
+}
+
+// Learn teaches every Trainer that the comment is spam or not
+func (p *Pipeline) Learn(ctx context.Context, c *Comment, spam bool) {
+	for _, checker := range p.Checkers {
+		if trainer, ok := checker.(Trainer); ok {
+			if err := trainer.Learn(ctx, c, spam); err != nil {
+				log.Printf("spam: %T failed to learn: %v", checker, err)
+			}
+		}
+	}
+}
+
+// Forget undoes an earlier Learn, before a moderator changes the decision
+func (p *Pipeline) Forget(ctx context.Context, c *Comment, spam bool) {
+	for _, checker := range p.Checkers {
+		if trainer, ok := checker.(Trainer); ok {
+			if err := trainer.Forget(ctx, c, spam); err != nil {
+				log.Printf("spam: %T failed to forget: %v", checker, err)
+			}
+		}
+	}
+}
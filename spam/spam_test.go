@@ -0,0 +1,83 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// checkerFunc turns a function into a SpamChecker
+type checkerFunc func(ctx context.Context, c *Comment) (Verdict, error)
+
+func (f checkerFunc) Check(ctx context.Context, c *Comment) (Verdict, error) {
+	return f(ctx, c)
+}
+
+func score(s float64, reason string) SpamChecker {
+	return checkerFunc(func(ctx context.Context, c *Comment) (Verdict, error) {
+		return Verdict{Score: s, Reason: reason}, nil
+	})
+}
+
+func TestPipelineEvaluate(t *testing.T) {
+	failing := checkerFunc(func(ctx context.Context, c *Comment) (Verdict, error) {
+		return Verdict{Score: 5, Reason: "failed"}, errors.New("unavailable")
+	})
+
+	tests := []struct {
+		name     string
+		checkers []SpamChecker
+		want     Result
+	}{
+		{"no checkers", nil, Result{Action: ActionAllow}},
+		{"nothing found", []SpamChecker{score(0, "zero"), score(-1, "negative")}, Result{Action: ActionAllow}},
+		{"below hold", []SpamChecker{score(0.2, "a")}, Result{Score: 0.2, Reasons: []string{"a"}, Action: ActionAllow}},
+		{"scores add up to hold", []SpamChecker{score(0.25, "a"), score(0.25, "b")},
+			Result{Score: 0.5, Reasons: []string{"a", "b"}, Action: ActionHold}},
+		{"reject stops the pipeline", []SpamChecker{score(1, "a"), score(1, "b")},
+			Result{Score: 1, Reasons: []string{"a"}, Action: ActionReject}},
+		{"failing checkers are skipped", []SpamChecker{failing, score(0.6, "a")},
+			Result{Score: 0.6, Reasons: []string{"a"}, Action: ActionHold}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Pipeline{Checkers: tt.checkers, HoldScore: 0.5, RejectScore: 1}
+			got := p.Evaluate(context.Background(), &Comment{Content: "hello"})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineWithLocalCheckers(t *testing.T) {
+	p := &Pipeline{
+		HoldScore:   0.5,
+		RejectScore: 1,
+		Checkers: []SpamChecker{
+			HoneypotChecker{Score: 1},
+			BlocklistChecker{Domains: []string{"spam.test"}, Score: 1},
+			LinkChecker{Max: 2, Weight: 0.25},
+		},
+	}
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"thanks for the post", ActionAllow},
+		{"https://a.test https://b.test https://c.test https://d.test", ActionHold},
+		{"buy at https://spam.test", ActionReject},
+	}
+	for _, tt := range tests {
+		if got := p.Evaluate(context.Background(), &Comment{Content: tt.content}); got.Action != tt.want {
+			t.Errorf("Evaluate(%q) = %+v, want %s", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestResultReason(t *testing.T) {
+	if got := (Result{Reasons: []string{"3 links", "honeypot"}}).Reason(); got != "3 links, honeypot" {
+		t.Errorf("Reason = %q", got)
+	}
+}
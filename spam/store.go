@@ -0,0 +1,53 @@
+package spam
+
+import (
+	"context"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore keeps the Bayes token counts in the spam_tokens table
+type GormStore struct {
+	DB *gorm.DB
+}
+
+func (s GormStore) Counts(ctx context.Context, tokens []string) (map[string]TokenCount, error) {
+	var rows []models.SpamToken
+	if err := s.DB.WithContext(ctx).Where("token IN ?", tokens).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]TokenCount, len(rows))
+	for _, row := range rows {
+		counts[row.Token] = TokenCount{Spam: row.Spam, Ham: row.Ham}
+	}
+	return counts, nil
+}
+
+func (s GormStore) Add(ctx context.Context, tokens []string, spam bool, delta int64) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	column := "ham"
+	if spam {
+		column = "spam"
+	}
+	rows := make([]models.SpamToken, len(tokens))
+	for i, token := range tokens {
+		rows[i].Token = token
+		if delta > 0 {
+			if spam {
+				rows[i].Spam = delta
+			} else {
+				rows[i].Ham = delta
+			}
+		}
+	}
+	// 이미 있는 토큰은 개수만 변경, 0보다 작아지지 않음
+	return s.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			column: gorm.Expr("GREATEST("+column+" + ?, 0)", delta),
+		}),
+	}).Create(&rows).Error
+}
@@ -1,12 +1,22 @@
 package util
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
+	"github.com/bloomingFlower/blog-backend/models"
 	jwt "github.com/dgrijalva/jwt-go"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 var SecretKey string
@@ -45,3 +55,117 @@ func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
 }
+
+// Slugify converts a title into a URL friendly slug. Letters of any script are
+// kept so that Korean titles still produce readable slugs.
+func Slugify(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(title)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if runes := []rune(slug); len(runes) > 80 {
+		slug = strings.TrimSuffix(string(runes[:80]), "-")
+	}
+	return slug
+}
+
+// UniquePostSlug slugifies s and appends the post ID when another post
+// already uses the same slug
+func UniquePostSlug(db *gorm.DB, s string, postID uint) string {
+	slug := Slugify(s)
+	if slug == "" {
+		return strconv.Itoa(int(postID))
+	}
+	// 숫자로만 된 slug는 포스트 ID와 구분되지 않음
+	if _, err := strconv.Atoi(slug); err == nil {
+		slug = "post-" + slug
+	}
+	// 휴지통에 있는 포스트도 고유 인덱스에 포함됨
+	var count int64
+	db.Unscoped().Model(&models.Post{}).Where("slug = ? AND id <> ?", slug, postID).Count(&count)
+	if count > 0 {
+		slug = fmt.Sprintf("%s-%d", slug, postID)
+	}
+	return slug
+}
+
+// PostUnlockToken signs access to a password protected post until expires.
+// The password hash is part of the signature, so changing the password
+// invalidates every token.
+func PostUnlockToken(postID uint, passwordHash []byte, expires time.Time) string {
+	payload := fmt.Sprintf("%d.%d", postID, expires.Unix())
+	return payload + "." + postUnlockSignature(payload, passwordHash)
+}
+
+// VerifyPostUnlockToken reports whether token unlocks the post
+func VerifyPostUnlockToken(token string, postID uint, passwordHash []byte) bool {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != strconv.Itoa(int(postID)) {
+		return false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	expected := postUnlockSignature(parts[0]+"."+parts[1], passwordHash)
+	return hmac.Equal([]byte(parts[2]), []byte(expected))
+}
+
+func postUnlockSignature(payload string, passwordHash []byte) string {
+	mac := hmac.New(sha256.New, []byte(SecretKey))
+	mac.Write([]byte(payload))
+	mac.Write(passwordHash)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecretToken returns a random token, such as a share link or comment
+// delete token, and the hash that is stored instead of it
+func NewSecretToken() (string, string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashSecretToken(token), nil
+}
+
+// HashSecretToken returns the hex SHA-256 hash stored for a secret token
+func HashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// UnsubscribeToken signs the one-click link that turns off notification
+// emails for the user
+func UnsubscribeToken(userID uint) string {
+	payload := strconv.FormatUint(uint64(userID), 10)
+	return payload + "." + unsubscribeSignature(payload)
+}
+
+// VerifyUnsubscribeToken returns the user an unsubscribe token was made for
+func VerifyUnsubscribeToken(token string) (uint, bool) {
+	payload, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(payload, 10, 32)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(payload))) {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func unsubscribeSignature(payload string) string {
+	mac := hmac.New(sha256.New, []byte(SecretKey))
+	mac.Write([]byte("unsubscribe." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello World", "hello-world"},
+		{"  Hello,   World!  ", "hello-world"},
+		{"Go 1.21 release notes", "go-1-21-release-notes"},
+		{"안녕하세요 세계", "안녕하세요-세계"},
+		{"Ünïcödé Straße", "ünïcödé-straße"},
+		{"--already-a-slug--", "already-a-slug"},
+		{"!!!", ""},
+		{"", ""},
+		{strings.Repeat("a", 100), strings.Repeat("a", 80)},
+		{strings.Repeat("가", 79) + " b", strings.Repeat("가", 79)},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.title); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+// dryRunDB builds queries without a database; counts are always 0
+func dryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:1)/blog",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUniquePostSlug(t *testing.T) {
+	db := dryRunDB(t)
+	tests := []struct {
+		s      string
+		postID uint
+		want   string
+	}{
+		{"Hello World", 3, "hello-world"},
+		{"!!!", 3, "3"},
+		{"2024", 3, "post-2024"},
+		{"already-clean", 3, "already-clean"},
+	}
+	for _, tt := range tests {
+		if got := UniquePostSlug(db, tt.s, tt.postID); got != tt.want {
+			t.Errorf("UniquePostSlug(%q, %d) = %q, want %q", tt.s, tt.postID, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyPostUnlockToken(t *testing.T) {
+	hash := []byte("$2a$14$old")
+	valid := PostUnlockToken(7, hash, time.Now().Add(time.Hour))
+	expired := PostUnlockToken(7, hash, time.Now().Add(-time.Minute))
+	payload, _, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		postID uint
+		hash   []byte
+		want   bool
+	}{
+		{"valid", valid, 7, hash, true},
+		{"other post", valid, 8, hash, false},
+		{"password changed", valid, 7, []byte("$2a$14$new"), false},
+		{"expired", expired, 7, hash, false},
+		{"tampered expiry", payload + ".9999999999." + strings.Split(valid, ".")[2], 7, hash, false},
+		{"bad signature", valid + "x", 7, hash, false},
+		{"malformed", "7.abc", 7, hash, false},
+		{"empty", "", 7, hash, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPostUnlockToken(tt.token, tt.postID, tt.hash); got != tt.want {
+				t.Errorf("VerifyPostUnlockToken(%q) = %t, want %t", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSecretToken(t *testing.T) {
+	token, hash, err := NewSecretToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 32 || strings.ContainsAny(token, "+/=") {
+		t.Errorf("token = %q, want 32 URL-safe characters", token)
+	}
+	if hash != HashSecretToken(token) || len(hash) != 64 {
+		t.Errorf("hash = %q, want the SHA-256 hex of the token", hash)
+	}
+	if other, _, _ := NewSecretToken(); other == token {
+		t.Error("NewSecretToken returned the same token twice")
+	}
+}
+
+func TestHashSecretToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+	for _, tt := range tests {
+		if got := HashSecretToken(tt.token); got != tt.want {
+			t.Errorf("HashSecretToken(%q) = %q, want %q", tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyUnsubscribeToken(t *testing.T) {
+	token := UnsubscribeToken(42)
+	payload, signature, _ := strings.Cut(token, ".")
+	tests := []struct {
+		name   string
+		token  string
+		wantID uint
+		wantOK bool
+	}{
+		{"valid", token, 42, true},
+		{"other user", "43." + signature, 0, false},
+		{"bad signature", payload + ".x", 0, false},
+		{"no signature", payload, 0, false},
+		{"not a number", "abc." + signature, 0, false},
+		// 잠금 해제 토큰과 서명이 섞이지 않는다
+		{"post unlock token", PostUnlockToken(42, nil, time.Now().Add(time.Hour)), 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := VerifyUnsubscribeToken(tt.token)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("VerifyUnsubscribeToken(%q) = %d, %t, want %d, %t", tt.token, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"gorm.io/gorm"
+)
+
+// HashIP returns the salted hash stored for an IP address, so rate limits,
+// de-duplication and moderators can match addresses without keeping them.
+// The salt is IP_HASH_SALT, or JWT_SECRET when it is not set; changing it
+// stops new hashes from matching old ones.
+func HashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	salt := os.Getenv("IP_HASH_SALT")
+	if salt == "" {
+		salt = SecretKey
+	}
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IPRetention returns how long raw IP addresses are kept, from
+// IP_RETENTION_DAYS (default 30). Zero keeps them forever.
+func IPRetention() time.Duration {
+	days := 30
+	if v := os.Getenv("IP_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			days = n
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ipModels are the records that kept the IP address of a commenter before
+// only hashes were stored
+var ipModels = []interface{}{&models.Comment{}, &models.CommentAudit{}, &models.Vote{}}
+
+// HashIPAddresses fills in the hash of records that only have a raw IP
+// address, such as those saved before hashes were stored
+func HashIPAddresses(db *gorm.DB) error {
+	for _, model := range ipModels {
+		var ips []string
+		err := db.Unscoped().Model(model).Distinct("ip_address").
+			Where("ip_address <> '' AND (ip_hash = '' OR ip_hash IS NULL)").
+			Pluck("ip_address", &ips).Error
+		if err != nil {
+			return err
+		}
+		for _, ip := range ips {
+			err := db.Unscoped().Model(model).
+				Where("ip_address = ? AND (ip_hash = '' OR ip_hash IS NULL)", ip).
+				UpdateColumn("ip_hash", HashIP(ip)).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// 예전 익명 반응의 고유 키도 IP 대신 해시로 바꿈
+	var ips []string
+	err := db.Unscoped().Model(&models.Vote{}).Distinct("ip_address").
+		Where("ip_address <> '' AND voter = CONCAT('ip:', ip_address)").
+		Pluck("ip_address", &ips).Error
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		err := db.Unscoped().Model(&models.Vote{}).
+			Where("voter = ?", "ip:"+ip).
+			UpdateColumn("voter", "ip:"+HashIP(ip)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EraseIPAddresses hashes and then erases the raw IP addresses recorded
+// before the given time, including those of API logs, and returns how many
+// records were changed. New comments, votes and audit records only store the
+// hash, so this only erases older records.
+func EraseIPAddresses(db *gorm.DB, before time.Time) (int64, error) {
+	if err := HashIPAddresses(db); err != nil {
+		return 0, err
+	}
+	var erased int64
+	for _, model := range ipModels {
+		result := db.Unscoped().Model(model).
+			Where("ip_address <> '' AND created_at < ?", before).
+			UpdateColumn("ip_address", "")
+		if result.Error != nil {
+			return erased, result.Error
+		}
+		erased += result.RowsAffected
+	}
+	result := db.Unscoped().Model(&models.APILog{}).
+		Where("request_ip <> '' AND created_at < ?", before).
+		UpdateColumn("request_ip", "")
+	return erased + result.RowsAffected, result.Error
+}
+
+// EraseIPAddressesEvery erases IP addresses older than IPRetention at every
+// interval. It blocks, so run it in its own goroutine.
+func EraseIPAddressesEvery(db *gorm.DB, interval time.Duration) {
+	retention := IPRetention()
+	if retention == 0 {
+		if err := HashIPAddresses(db); err != nil {
+			log.Printf("Failed to hash IP addresses: %v", err)
+		}
+		return
+	}
+	for ; ; time.Sleep(interval) {
+		n, err := EraseIPAddresses(db, time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Failed to erase IP addresses: %v", err)
+		}
+		if n > 0 {
+			log.Printf("Erased the IP addresses of %d records", n)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHashIP(t *testing.T) {
+	t.Setenv("IP_HASH_SALT", "")
+	if got := HashIP(""); got != "" {
+		t.Errorf("HashIP(\"\") = %q, want empty", got)
+	}
+	hash := HashIP("203.0.113.7")
+	if len(hash) != 64 || hash != HashIP("203.0.113.7") {
+		t.Errorf("HashIP = %q, want a stable SHA-256 hex", hash)
+	}
+	if hash == HashIP("203.0.113.8") {
+		t.Error("different IPs have the same hash")
+	}
+
+	// IP_HASH_SALT가 바뀌면 예전 해시와 일치하지 않는다
+	t.Setenv("IP_HASH_SALT", "pepper")
+	if salted := HashIP("203.0.113.7"); salted == hash {
+		t.Error("IP_HASH_SALT does not change the hash")
+	}
+}
+
+func TestIPRetention(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 30 * 24 * time.Hour},
+		{"90", 90 * 24 * time.Hour},
+		{"0", 0},
+		{"-1", 30 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Setenv("IP_RETENTION_DAYS", tt.env)
+		if got := IPRetention(); got != tt.want {
+			t.Errorf("IPRetention with IP_RETENTION_DAYS=%q = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/bloomingFlower/blog-backend/models"
+	"gorm.io/gorm"
+)
+
+// TrashRetention returns how long deleted posts stay in the trash before
+// they are purged, from POST_TRASH_DAYS (default 30). Zero disables the
+// automatic purge.
+func TrashRetention() time.Duration {
+	days := 30
+	if v := os.Getenv("POST_TRASH_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			days = n
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PurgePost permanently deletes a post together with its comments, votes,
+// collaborators, translations, attachments, import records and uploaded files
+func PurgePost(db *gorm.DB, postID uint, uploadDir string) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		comments := tx.Unscoped().Model(&models.Comment{}).Select("id").Where("post_id = ?", postID)
+		if err := tx.Unscoped().Where("comment_id IN (?)", comments).Delete(&models.Vote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id IN (?)", comments).Delete(&models.CommentRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id IN (?)", comments).Delete(&models.CommentMention{}).Error; err != nil {
+			return err
+		}
+		// 답글이 부모 댓글을 참조하므로 참조를 먼저 끊음
+		if err := tx.Unscoped().Model(&models.Comment{}).Where("post_id = ?", postID).UpdateColumn("parent_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("post_id = ?", postID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.CommentPolicy{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostCollaborator{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostImport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostTranslation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostAttachment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostShareLink{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostDailyView{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Post{}, postID).Error
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(uploadDir, strconv.Itoa(int(postID))))
+}
+
+// PurgeTrash purges the posts deleted before the given time and returns how
+// many were purged
+func PurgeTrash(db *gorm.DB, before time.Time, uploadDir string) (int, error) {
+	var ids []uint
+	if err := db.Unscoped().Model(&models.Post{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		if err := PurgePost(db, id, uploadDir); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
+// PurgeTrashEvery purges posts older than TrashRetention at every interval.
+// It blocks, so run it in its own goroutine.
+func PurgeTrashEvery(db *gorm.DB, uploadDir string, interval time.Duration) {
+	retention := TrashRetention()
+	if retention == 0 {
+		return
+	}
+	for ; ; time.Sleep(interval) {
+		n, err := PurgeTrash(db, time.Now().Add(-retention), uploadDir)
+		if err != nil {
+			log.Printf("Failed to purge trash: %v", err)
+		}
+		if n > 0 {
+			log.Printf("Purged %d posts from the trash", n)
+		}
+	}
+}
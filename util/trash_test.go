@@ -0,0 +1,25 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrashRetention(t *testing.T) {
+	tests := []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", 30 * 24 * time.Hour},
+		{"7", 7 * 24 * time.Hour},
+		{"0", 0},
+		{"-1", 30 * 24 * time.Hour},
+		{"week", 30 * 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Setenv("POST_TRASH_DAYS", tt.env)
+		if got := TrashRetention(); got != tt.want {
+			t.Errorf("TrashRetention with POST_TRASH_DAYS=%q = %v, want %v", tt.env, got, tt.want)
+		}
+	}
+}